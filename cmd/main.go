@@ -2,17 +2,29 @@ package main
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 
-	"github.com/v1adhope/auth-service/internal/app"
+	"github.com/v1adhope/auth-service/internal/cli"
 )
 
 func main() {
-	ctx := context.Background()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 
-	cfg := app.MustConfig()
+	err := cli.Run(ctx, os.Args[1:], os.Stdout)
 
-	if err := app.Run(ctx, cfg); err != nil {
-		log.Fatal(err)
+	stop()
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+
+		if errors.Is(err, cli.ErrUsage) {
+			os.Exit(2)
+		}
+
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+func Source() (source.Driver, error) {
+	d, err := iofs.New(migrations, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("db: migrations: Source: New: %w", err)
+	}
+
+	return d, nil
+}
+
+// INFO: the postgres driver holds a pg advisory lock around Up/Down/Steps/Force,
+// so concurrent replicas apply migrations one at a time
+func NewMigrate(databaseUrl string) (*migrate.Migrate, error) {
+	src, err := Source()
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", src, databaseUrl)
+	if err != nil {
+		return nil, fmt.Errorf("db: migrations: NewMigrate: NewWithSourceInstance: %w", err)
+	}
+
+	return m, nil
+}
+
+// INFO: the schema version this binary understands
+func LatestVersion() (uint, error) {
+	src, err := Source()
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	v, err := src.First()
+	if err != nil {
+		return 0, fmt.Errorf("db: migrations: LatestVersion: First: %w", err)
+	}
+
+	for {
+		next, err := src.Next(v)
+		if errors.Is(err, fs.ErrNotExist) {
+			return v, nil
+		}
+
+		if err != nil {
+			return 0, fmt.Errorf("db: migrations: LatestVersion: Next: %w", err)
+		}
+
+		v = next
+	}
+}
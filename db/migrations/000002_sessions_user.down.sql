@@ -0,0 +1,3 @@
+drop index if exists auth_whitelist_user_id;
+
+alter table auth_whitelist drop column if exists user_id;
@@ -0,0 +1,3 @@
+alter table auth_whitelist add column if not exists user_id uuid;
+
+create index if not exists auth_whitelist_user_id on auth_whitelist(user_id);
@@ -0,0 +1,10 @@
+create table if not exists auth_grace(
+  id uuid,
+  token varchar(255),
+  successor text,
+  expires_at timestamp,
+
+  constraint auth_grace_id primary key (id)
+);
+
+create index if not exists auth_grace_expires_at on auth_grace(expires_at);
@@ -0,0 +1,11 @@
+create table if not exists auth_idempotency(
+  key varchar(64),
+  request_hash varchar(64),
+  session_id uuid,
+  response text,
+  expires_at timestamp,
+
+  constraint auth_idempotency_key primary key (key)
+);
+
+create index if not exists auth_idempotency_expires_at on auth_idempotency(expires_at);
@@ -0,0 +1,8 @@
+create table if not exists auth_users(
+  id uuid,
+  status varchar(16) not null default 'active',
+  email varchar(320) not null default '',
+  roles text[] not null default '{}',
+
+  constraint auth_users_id primary key (id)
+);
@@ -0,0 +1,3 @@
+drop index if exists auth_grace_successor_id;
+
+alter table auth_grace drop column if exists successor_id;
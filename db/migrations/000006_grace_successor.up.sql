@@ -0,0 +1,3 @@
+alter table auth_grace add column if not exists successor_id uuid;
+
+create index if not exists auth_grace_successor_id on auth_grace(successor_id);
@@ -0,0 +1,20 @@
+package db_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/v1adhope/auth-service/db"
+)
+
+func TestLatestVersion(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("migrations", "*.up.sql"))
+
+	assert.NoError(t, err)
+
+	sut, err := db.LatestVersion()
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(len(files)), sut)
+}
@@ -2,41 +2,103 @@ package app
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
 
 	"github.com/v1adhope/auth-service/internal/services"
 	"github.com/v1adhope/auth-service/internal/services/infrastructure/alert"
 	"github.com/v1adhope/auth-service/internal/services/infrastructure/hash"
-	"github.com/v1adhope/auth-service/internal/services/infrastructure/repositories"
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/metrics"
 	"github.com/v1adhope/auth-service/internal/services/infrastructure/tokens"
 	"github.com/v1adhope/auth-service/internal/services/infrastructure/validator"
 	httpv1 "github.com/v1adhope/auth-service/internal/transports/http/v1"
+	"github.com/v1adhope/auth-service/pkg/clientip"
 	"github.com/v1adhope/auth-service/pkg/httpserver"
 	"github.com/v1adhope/auth-service/pkg/logger"
-	"github.com/v1adhope/auth-service/pkg/postgresql"
+	"github.com/v1adhope/auth-service/pkg/tracing"
 )
 
-func Run(ctx context.Context, cfg Config) error {
+// INFO: runs until ctx is done, then shuts down the http server,
+// background workers and the whitelist storage in that order.
+// SIGHUP reloads the config from configPath, see reloadable
+func Run(ctx context.Context, configPath string) error {
+	cfg, err := LoadConfig(ctx, configPath)
+	if err != nil {
+		return err
+	}
+
 	validator := validator.New()
 
-	tokenManager := tokens.New(
+	tokenManager, err := tokens.New(
 		tokens.WithAccessKey(cfg.Tokens.AccessKey),
 		tokens.WithAccessTtl(cfg.Tokens.AceessTtl),
 		tokens.WithRefreshKey(cfg.Tokens.RefreshKey),
+		tokens.WithRefreshKeyRetention(cfg.Storage.Ttl),
 		tokens.WithIssuer(cfg.Tokens.Issuer),
 	)
+	if err != nil {
+		return err
+	}
 
-	hash := hash.New()
+	metrics := metrics.New()
 
-	postgres, err := postgresql.Build(
+	hash := hash.New(
+		hash.WithObserver(metrics),
+		hash.WithMode(cfg.Hash.Mode),
+		hash.WithPepper(cfg.Hash.Pepper),
+		hash.WithBcryptCost(cfg.Hash.BcryptCost),
+		hash.WithArgon2(cfg.Hash.argon2()),
+		hash.WithArgon2Max(cfg.Hash.argon2Max()),
+	)
+
+	log := logger.New(
+		logger.WithLevel(cfg.Logger.Level),
+	)
+
+	tracing, err := tracing.Build(
 		ctx,
-		postgresql.WithConnStr(cfg.Postgres.ConnStr),
+		tracing.WithExporter(cfg.Tracing.Exporter),
+		tracing.WithEndpoint(cfg.Tracing.Endpoint),
+		tracing.WithInsecure(cfg.Tracing.Insecure),
+		tracing.WithServiceName(cfg.Tokens.Issuer),
+		tracing.WithSampleRatio(cfg.Tracing.SampleRatio),
 	)
 	if err != nil {
 		return err
 	}
-	defer postgres.Close()
+	defer func() {
+		if err := tracing.Shutdown(context.Background()); err != nil {
+			log.Error(ctx, err, "%s", "tracing shutdown")
+		}
+	}()
+
+	storage, err := buildStorage(ctx, cfg, log, metrics)
+	if err != nil {
+		return err
+	}
+	defer storage.close()
+
+	workers := newWorkers()
+	defer func() {
+		if err := workers.Stop(); err != nil {
+			log.Error(ctx, err, "%s", "workers stopped")
+			return
+		}
+
+		log.Info(ctx, "%s", "workers stopped")
+	}()
 
-	repos := repositories.New(postgres)
+	for _, job := range storage.jobs {
+		workers.Go(job)
+	}
+
+	users, err := buildUsers(ctx, cfg, log, storage)
+	if err != nil {
+		return err
+	}
+	defer users.close()
 
 	alert := alert.New()
 
@@ -44,30 +106,97 @@ func Run(ctx context.Context, cfg Config) error {
 		validator,
 		tokenManager,
 		hash,
-		repos,
+		storage.repo,
 		alert,
+		metrics,
+		services.WithRefreshGrace(cfg.Tokens.RefreshGrace),
+		services.WithIdempotencyTtl(cfg.Tokens.IdempotencyTtl),
+		services.WithUserDirectory(users.dir),
 	)
 
-	log := logger.New(
-		logger.WithLevel(cfg.Logger.Level),
+	clientIp, err := clientip.New(
+		clientip.WithMode(cfg.Server.ClientIp.Mode),
+		clientip.WithTrustedProxies(cfg.Server.ClientIp.TrustedProxies),
 	)
+	if err != nil {
+		return err
+	}
 
-	handler := httpv1.New(services, log).Handler(
-		httpv1.WithAllowOrigins(cfg.Server.AllowOrigins),
-		httpv1.WithAllowMethods(cfg.Server.AllowMethods),
-		httpv1.WithAllowHeaders(cfg.Server.AllowHeaders),
+	cookie, err := refreshCookie(cfg)
+	if err != nil {
+		return err
+	}
+
+	publicCors, adminCors, err := corsPolicies(cfg)
+	if err != nil {
+		return err
+	}
+
+	router := httpv1.New(services, log)
+
+	handlerOpts := []httpv1.Option{
+		httpv1.WithCors(publicCors),
 		httpv1.WithMode(cfg.Server.Mode),
-	)
+		httpv1.WithAccessLogSampleRate(cfg.Logger.AccessSampleRate),
+		httpv1.WithAccessLogBodies(cfg.Logger.AccessBodies),
+		httpv1.WithMetrics(metrics),
+		httpv1.WithReadinessProbe("keys", tokenManager.Ready),
+		httpv1.WithAdminMtls(cfg.Server.Tls.ClientCaFile != ""),
+		httpv1.WithClientIpResolver(clientIp),
+		httpv1.WithRefreshCookie(cookie),
+	}
+
+	if adminCors != nil {
+		handlerOpts = append(handlerOpts, httpv1.WithAdminCors(*adminCors))
+	}
+
+	handlerOpts = append(handlerOpts, storage.probes...)
+	handlerOpts = append(handlerOpts, users.probes...)
+
+	handler := router.Handler(handlerOpts...)
+
+	running := newRunningConfig(cfg)
+
+	workers.Go(reloadOnHangup(configPath, running, reloadable{log, tokenManager, router}))
+
+	src, err := secretSource(cfg)
+	if err != nil {
+		return err
+	}
+
+	if src != nil && cfg.Secrets.RefreshInterval > 0 {
+		workers.Go(refreshSecrets(src, cfg.Secrets.RefreshInterval, running, tokenManager, log))
+	}
+
+	socketMode, err := strconv.ParseUint(cfg.Server.SocketMode, 8, 32)
+	if err != nil {
+		return fmt.Errorf("app: app: Run: socket mode: %w", err)
+	}
 
 	s := httpserver.New(
 		handler,
-		httpserver.WithSocket(cfg.Server.Socket),
+		httpserver.WithSockets(cfg.Server.Sockets),
+		httpserver.WithSocketMode(os.FileMode(socketMode)),
 		httpserver.WithShutdownTimeout(cfg.Server.ShutdownTimeout),
 		httpserver.WithWriteTimeout(cfg.Server.WriteTimeout),
 		httpserver.WithReadTimeout(cfg.Server.ReadTimeout),
+		httpserver.WithDrainDelay(cfg.Server.DrainDelay),
+		httpserver.WithOnShutdown(router.Drain),
+		httpserver.WithTls(cfg.Server.Tls.CertFile, cfg.Server.Tls.KeyFile),
+		httpserver.WithClientCa(cfg.Server.Tls.ClientCaFile),
+		httpserver.WithTlsReloadInterval(cfg.Server.Tls.ReloadInterval),
+		httpserver.WithOnTlsReloadError(func(err error) {
+			log.Error(ctx, err, "%s", "tls reload failed, serving the previous certificate")
+		}),
 	)
 
-	s.Run()
+	log.Info(ctx, "server listening on %s", strings.Join(cfg.Server.Sockets, ", "))
+
+	if err := s.Run(ctx); err != nil {
+		return err
+	}
+
+	log.Info(ctx, "%s", "server stopped")
 
 	return nil
 }
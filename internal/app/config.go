@@ -1,58 +1,201 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"sync"
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/joho/godotenv"
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/hash"
 )
 
 type (
 	Config struct {
-		Tokens   Tokens
-		Postgres Postgres
-		Logger   Logger
-		Server   Server
+		Tokens   Tokens   `yaml:"tokens" toml:"tokens"`
+		Hash     Hash     `yaml:"hash" toml:"hash"`
+		Storage  Storage  `yaml:"storage" toml:"storage"`
+		Postgres Postgres `yaml:"postgres" toml:"postgres"`
+		Logger   Logger   `yaml:"logger" toml:"logger"`
+		Server   Server   `yaml:"server" toml:"server"`
+		Tracing  Tracing  `yaml:"tracing" toml:"tracing"`
+		Secrets  Secrets  `yaml:"secrets" toml:"secrets"`
+		Users    Users    `yaml:"users" toml:"users"`
 	}
 
 	Tokens struct {
-		AccessKey  string        `env-required:"true" env:"APP_TOKENS_ACCESS_KEY"`
-		AceessTtl  time.Duration `env-required:"true" env:"APP_TOKENS_ACCESS_TTL"`
-		RefreshKey string        `env-required:"true" env:"APP_TOKENS_REFRESH_KEY"`
-		Issuer     string        `env-required:"true" env:"APP_TOKENS_ISSUER"`
+		AccessKey      string        `yaml:"access_key" toml:"access_key" env:"APP_TOKENS_ACCESS_KEY"`
+		AccessKeyFile  string        `yaml:"access_key_file" toml:"access_key_file" env:"APP_TOKENS_ACCESS_KEY_FILE"`
+		AceessTtl      time.Duration `yaml:"access_ttl" toml:"access_ttl" env-required:"true" env:"APP_TOKENS_ACCESS_TTL"`
+		RefreshKey     string        `yaml:"refresh_key" toml:"refresh_key" env:"APP_TOKENS_REFRESH_KEY"`
+		RefreshKeyFile string        `yaml:"refresh_key_file" toml:"refresh_key_file" env:"APP_TOKENS_REFRESH_KEY_FILE"`
+		Issuer         string        `yaml:"issuer" toml:"issuer" env-required:"true" env:"APP_TOKENS_ISSUER"`
+		RefreshGrace   time.Duration `yaml:"refresh_grace" toml:"refresh_grace" env-default:"0s" env:"APP_TOKENS_REFRESH_GRACE"`
+		IdempotencyTtl time.Duration `yaml:"idempotency_ttl" toml:"idempotency_ttl" env-default:"24h" env:"APP_TOKENS_IDEMPOTENCY_TTL"`
+	}
+
+	Hash struct {
+		Mode                 string `yaml:"mode" toml:"mode" env-default:"bcrypt" env:"APP_HASH_MODE"`
+		Pepper               string `yaml:"pepper" toml:"pepper" env:"APP_HASH_PEPPER"`
+		PepperFile           string `yaml:"pepper_file" toml:"pepper_file" env:"APP_HASH_PEPPER_FILE"`
+		BcryptCost           int    `yaml:"bcrypt_cost" toml:"bcrypt_cost" env-default:"10" env:"APP_HASH_BCRYPT_COST"`
+		Argon2Memory         uint32 `yaml:"argon2_memory" toml:"argon2_memory" env-default:"65536" env:"APP_HASH_ARGON2_MEMORY"`
+		Argon2Time           uint32 `yaml:"argon2_time" toml:"argon2_time" env-default:"3" env:"APP_HASH_ARGON2_TIME"`
+		Argon2Parallelism    uint8  `yaml:"argon2_parallelism" toml:"argon2_parallelism" env-default:"4" env:"APP_HASH_ARGON2_PARALLELISM"`
+		Argon2MaxMemory      uint32 `yaml:"argon2_max_memory" toml:"argon2_max_memory" env-default:"262144" env:"APP_HASH_ARGON2_MAX_MEMORY"`
+		Argon2MaxTime        uint32 `yaml:"argon2_max_time" toml:"argon2_max_time" env-default:"16" env:"APP_HASH_ARGON2_MAX_TIME"`
+		Argon2MaxParallelism uint8  `yaml:"argon2_max_parallelism" toml:"argon2_max_parallelism" env-default:"16" env:"APP_HASH_ARGON2_MAX_PARALLELISM"`
+	}
+
+	Storage struct {
+		Driver       string        `yaml:"driver" toml:"driver" env-default:"postgres" env:"APP_STORAGE_DRIVER"`
+		Ttl          time.Duration `yaml:"ttl" toml:"ttl" env-default:"720h" env:"APP_STORAGE_TTL"`
+		RedisUrl     string        `yaml:"redis_url" toml:"redis_url" env:"APP_STORAGE_REDIS_URL"`
+		RedisUrlFile string        `yaml:"redis_url_file" toml:"redis_url_file" env:"APP_STORAGE_REDIS_URL_FILE"`
 	}
 
 	Postgres struct {
-		ConnStr string `env-required:"true" env:"APP_POSTGRES_CONN_STR"`
+		ConnStr     string `yaml:"conn_str" toml:"conn_str" env:"APP_POSTGRES_CONN_STR"`
+		ConnStrFile string `yaml:"conn_str_file" toml:"conn_str_file" env:"APP_POSTGRES_CONN_STR_FILE"`
+		AutoMigrate bool   `yaml:"auto_migrate" toml:"auto_migrate" env-default:"false" env:"APP_POSTGRES_AUTO_MIGRATE"`
 	}
 
 	Logger struct {
-		Level string `env-required:"true" env:"APP_LOGGER_LEVEL"`
+		Level            string  `yaml:"level" toml:"level" env-required:"true" env:"APP_LOGGER_LEVEL"`
+		AccessSampleRate float64 `yaml:"access_sample_rate" toml:"access_sample_rate" env-default:"1" env:"APP_LOGGER_ACCESS_SAMPLE_RATE"`
+		AccessBodies     bool    `yaml:"access_bodies" toml:"access_bodies" env-default:"false" env:"APP_LOGGER_ACCESS_BODIES"`
+	}
+
+	Tracing struct {
+		Exporter    string  `yaml:"exporter" toml:"exporter" env-default:"none" env:"APP_TRACING_EXPORTER"`
+		Endpoint    string  `yaml:"endpoint" toml:"endpoint" env:"APP_TRACING_ENDPOINT"`
+		Insecure    bool    `yaml:"insecure" toml:"insecure" env-default:"false" env:"APP_TRACING_INSECURE"`
+		SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio" env-default:"1" env:"APP_TRACING_SAMPLE_RATIO"`
+	}
+
+	// INFO: external store consulted for the secret fields, see secretFields
+	Secrets struct {
+		Source          string        `yaml:"source" toml:"source" env:"APP_SECRETS_SOURCE"`
+		VaultAddr       string        `yaml:"vault_addr" toml:"vault_addr" env:"APP_SECRETS_VAULT_ADDR"`
+		VaultToken      string        `yaml:"vault_token" toml:"vault_token" env:"APP_SECRETS_VAULT_TOKEN"`
+		VaultTokenFile  string        `yaml:"vault_token_file" toml:"vault_token_file" env:"APP_SECRETS_VAULT_TOKEN_FILE"`
+		VaultPath       string        `yaml:"vault_path" toml:"vault_path" env:"APP_SECRETS_VAULT_PATH"`
+		VaultNamespace  string        `yaml:"vault_namespace" toml:"vault_namespace" env:"APP_SECRETS_VAULT_NAMESPACE"`
+		RefreshInterval time.Duration `yaml:"refresh_interval" toml:"refresh_interval" env-default:"5m" env:"APP_SECRETS_REFRESH_INTERVAL"`
+	}
+
+	// INFO: where the users tokens are issued for are looked up, see buildUsers
+	Users struct {
+		Directory string        `yaml:"directory" toml:"directory" env-default:"none" env:"APP_USERS_DIRECTORY"`
+		Url       string        `yaml:"url" toml:"url" env:"APP_USERS_URL"`
+		Token     string        `yaml:"token" toml:"token" env:"APP_USERS_TOKEN"`
+		TokenFile string        `yaml:"token_file" toml:"token_file" env:"APP_USERS_TOKEN_FILE"`
+		Timeout   time.Duration `yaml:"timeout" toml:"timeout" env-default:"5s" env:"APP_USERS_TIMEOUT"`
 	}
 
 	Server struct {
-		AllowOrigins    []string      `env-required:"true" env-separator:":" env:"APP_SERVER_ALLOW_ORIGINS"`
-		AllowMethods    []string      `env-required:"true" env-separator:":" env:"APP_SERVER_ALLOW_METHODS"`
-		AllowHeaders    []string      `env-required:"true" env-separator:":" env:"APP_SERVER_ALLOW_HEADERS"`
-		Mode            string        `env-required:"true" env:"APP_SERVER_MODE"`
-		Socket          string        `env-required:"true" env:"APP_SERVER_SOCKET"`
-		ShutdownTimeout time.Duration `env-required:"true" env:"APP_SERVER_SHUTDOWN_TIMEOUT"`
-		WriteTimeout    time.Duration `env-required:"true" env:"APP_SERVER_WRITE_TIMEOUT"`
-		ReadTimeout     time.Duration `env-required:"true" env:"APP_SERVER_READ_TIMEOUT"`
+		Mode            string        `yaml:"mode" toml:"mode" env-required:"true" env:"APP_SERVER_MODE"`
+		Sockets         []string      `yaml:"sockets" toml:"sockets" env-required:"true" env-separator:"," env:"APP_SERVER_SOCKET"`
+		SocketMode      string        `yaml:"socket_mode" toml:"socket_mode" env-default:"0660" env:"APP_SERVER_SOCKET_MODE"`
+		ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env-required:"true" env:"APP_SERVER_SHUTDOWN_TIMEOUT"`
+		WriteTimeout    time.Duration `yaml:"write_timeout" toml:"write_timeout" env-required:"true" env:"APP_SERVER_WRITE_TIMEOUT"`
+		ReadTimeout     time.Duration `yaml:"read_timeout" toml:"read_timeout" env-required:"true" env:"APP_SERVER_READ_TIMEOUT"`
+		DrainDelay      time.Duration `yaml:"drain_delay" toml:"drain_delay" env-default:"0s" env:"APP_SERVER_DRAIN_DELAY"`
+		Tls             Tls           `yaml:"tls" toml:"tls"`
+		ClientIp        ClientIp      `yaml:"client_ip" toml:"client_ip"`
+		RefreshCookie   RefreshCookie `yaml:"refresh_cookie" toml:"refresh_cookie"`
+		Cors            Cors          `yaml:"cors" toml:"cors"`
+		AdminCors       AdminCors     `yaml:"admin_cors" toml:"admin_cors"`
+	}
+
+	Cors struct {
+		AllowOrigins     []string      `yaml:"allow_origins" toml:"allow_origins" env-required:"true" env-separator:"," env:"APP_SERVER_ALLOW_ORIGINS"`
+		AllowMethods     []string      `yaml:"allow_methods" toml:"allow_methods" env-required:"true" env-separator:"," env:"APP_SERVER_ALLOW_METHODS"`
+		AllowHeaders     []string      `yaml:"allow_headers" toml:"allow_headers" env-required:"true" env-separator:"," env:"APP_SERVER_ALLOW_HEADERS"`
+		ExposeHeaders    []string      `yaml:"expose_headers" toml:"expose_headers" env-separator:"," env:"APP_SERVER_EXPOSE_HEADERS"`
+		AllowCredentials bool          `yaml:"allow_credentials" toml:"allow_credentials" env-default:"false" env:"APP_SERVER_ALLOW_CREDENTIALS"`
+		MaxAge           time.Duration `yaml:"max_age" toml:"max_age" env-default:"0s" env:"APP_SERVER_CORS_MAX_AGE"`
+	}
+
+	// INFO: routes outside /v1, the public policy applies when no origins are set
+	AdminCors struct {
+		AllowOrigins     []string      `yaml:"allow_origins" toml:"allow_origins" env-separator:"," env:"APP_SERVER_ADMIN_ALLOW_ORIGINS"`
+		AllowMethods     []string      `yaml:"allow_methods" toml:"allow_methods" env-separator:"," env:"APP_SERVER_ADMIN_ALLOW_METHODS"`
+		AllowHeaders     []string      `yaml:"allow_headers" toml:"allow_headers" env-separator:"," env:"APP_SERVER_ADMIN_ALLOW_HEADERS"`
+		ExposeHeaders    []string      `yaml:"expose_headers" toml:"expose_headers" env-separator:"," env:"APP_SERVER_ADMIN_EXPOSE_HEADERS"`
+		AllowCredentials bool          `yaml:"allow_credentials" toml:"allow_credentials" env-default:"false" env:"APP_SERVER_ADMIN_ALLOW_CREDENTIALS"`
+		MaxAge           time.Duration `yaml:"max_age" toml:"max_age" env-default:"0s" env:"APP_SERVER_ADMIN_CORS_MAX_AGE"`
+	}
+
+	RefreshCookie struct {
+		Enabled  bool   `yaml:"enabled" toml:"enabled" env-default:"false" env:"APP_SERVER_REFRESH_COOKIE"`
+		Name     string `yaml:"name" toml:"name" env-default:"refresh_token" env:"APP_SERVER_REFRESH_COOKIE_NAME"`
+		Path     string `yaml:"path" toml:"path" env-default:"/v1/tokens/refresh" env:"APP_SERVER_REFRESH_COOKIE_PATH"`
+		Domain   string `yaml:"domain" toml:"domain" env:"APP_SERVER_REFRESH_COOKIE_DOMAIN"`
+		SameSite string `yaml:"same_site" toml:"same_site" env-default:"strict" env:"APP_SERVER_REFRESH_COOKIE_SAME_SITE"`
+		Insecure bool   `yaml:"insecure" toml:"insecure" env-default:"false" env:"APP_SERVER_REFRESH_COOKIE_INSECURE"`
+	}
+
+	ClientIp struct {
+		Mode           string   `yaml:"mode" toml:"mode" env-default:"direct" env:"APP_SERVER_CLIENT_IP_MODE"`
+		TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies" env-separator:"," env:"APP_SERVER_TRUSTED_PROXIES"`
+	}
+
+	Tls struct {
+		CertFile       string        `yaml:"cert_file" toml:"cert_file" env:"APP_SERVER_TLS_CERT_FILE"`
+		KeyFile        string        `yaml:"key_file" toml:"key_file" env:"APP_SERVER_TLS_KEY_FILE"`
+		ClientCaFile   string        `yaml:"client_ca_file" toml:"client_ca_file" env:"APP_SERVER_TLS_CLIENT_CA_FILE"`
+		ReloadInterval time.Duration `yaml:"reload_interval" toml:"reload_interval" env-default:"10s" env:"APP_SERVER_TLS_RELOAD_INTERVAL"`
 	}
 )
 
-func MustConfig() Config {
-	if err := godotenv.Load(); err != nil {
-		panic(fmt.Errorf("config: can't load envs from .env: %v", err))
+var dotenvOnce = sync.OnceValue(func() error {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("app: config: dotenv: Load: %w", err)
 	}
 
+	return nil
+})
+
+// INFO: values from the yaml or toml file at path, when given, are overridden by env vars.
+// Without a file the optional .env is loaded once, godotenv sets it into the process env
+// and those values would otherwise pin every field over the file on reload.
+// Secrets are resolved before validation
+func LoadConfig(ctx context.Context, path string) (Config, error) {
 	cfg := Config{}
 
-	if err := cleanenv.ReadEnv(&cfg); err != nil {
-		panic(fmt.Sprintf("config: can't read envs: %v", err))
+	if path == "" {
+		if err := dotenvOnce(); err != nil {
+			return Config{}, err
+		}
+
+		if err := cleanenv.ReadEnv(&cfg); err != nil {
+			return Config{}, fmt.Errorf("app: config: LoadConfig: ReadEnv: %w", err)
+		}
+	} else {
+		if err := cleanenv.ReadConfig(path, &cfg); err != nil {
+			return Config{}, fmt.Errorf("app: config: LoadConfig: ReadConfig: %w", err)
+		}
 	}
 
-	return cfg
+	if err := cfg.resolveSecrets(ctx); err != nil {
+		return Config{}, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+func (h Hash) argon2() hash.Argon2 {
+	return hash.Argon2{Memory: h.Argon2Memory, Time: h.Argon2Time, Parallelism: h.Argon2Parallelism}
+}
+
+func (h Hash) argon2Max() hash.Argon2 {
+	return hash.Argon2{Memory: h.Argon2MaxMemory, Time: h.Argon2MaxTime, Parallelism: h.Argon2MaxParallelism}
 }
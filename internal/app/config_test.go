@@ -0,0 +1,262 @@
+package app_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/v1adhope/auth-service/internal/app"
+)
+
+const _yamlConfig = `
+tokens:
+  access_key: sOXgK2jwm7otKu2iq8uy8PN47DZ88T4EodChguRAZ5gUyifoHKU0u63BGlKnCQcW
+  access_ttl: 20m
+  refresh_key: MFYXyzEeCVX9wbHbpagdDwCWyacwwLb7
+  issuer: auth-service
+storage:
+  driver: memory
+logger:
+  level: info
+server:
+  mode: release
+  sockets: [":8080", "unix:///run/auth.sock"]
+  shutdown_timeout: 3s
+  write_timeout: 20s
+  read_timeout: 20s
+  cors:
+    allow_origins: ["https://*.example.com"]
+    allow_methods: [POST]
+    allow_headers: [Origin]
+`
+
+const _tomlConfig = `
+[tokens]
+access_key = "sOXgK2jwm7otKu2iq8uy8PN47DZ88T4EodChguRAZ5gUyifoHKU0u63BGlKnCQcW"
+access_ttl = "20m"
+refresh_key = "MFYXyzEeCVX9wbHbpagdDwCWyacwwLb7"
+issuer = "auth-service"
+
+[storage]
+driver = "memory"
+
+[logger]
+level = "info"
+
+[server]
+mode = "release"
+sockets = [":8080"]
+shutdown_timeout = "3s"
+write_timeout = "20s"
+read_timeout = "20s"
+
+[server.cors]
+allow_origins = ["*"]
+allow_methods = ["POST"]
+allow_headers = ["Origin"]
+`
+
+func writeConfig(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	tcs := []struct {
+		key     string
+		name    string
+		content string
+	}{
+		{
+			key:     "Yaml",
+			name:    "config.yaml",
+			content: _yamlConfig,
+		},
+		{
+			key:     "Toml",
+			name:    "config.toml",
+			content: _tomlConfig,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.key, func(t *testing.T) {
+			sut, err := app.LoadConfig(context.Background(), writeConfig(t, tc.name, tc.content))
+
+			assert.NoError(t, err, tc.key)
+			assert.Equal(t, 20*time.Minute, sut.Tokens.AceessTtl, tc.key)
+			assert.Equal(t, app.StorageMemory, sut.Storage.Driver, tc.key)
+			assert.Equal(t, []string{"POST"}, sut.Server.Cors.AllowMethods, tc.key)
+			assert.Equal(t, 720*time.Hour, sut.Storage.Ttl, tc.key)
+		})
+	}
+}
+
+func TestLoadConfigEnvOverrides(t *testing.T) {
+	t.Setenv("APP_LOGGER_LEVEL", "debug")
+	t.Setenv("APP_SERVER_ALLOW_ORIGINS", "https://a.example.com,https://b.example.com")
+
+	sut, err := app.LoadConfig(context.Background(), writeConfig(t, "config.yaml", _yamlConfig))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "debug", sut.Logger.Level)
+	assert.Equal(t, []string{"https://a.example.com", "https://b.example.com"}, sut.Server.Cors.AllowOrigins)
+}
+
+func TestLoadConfigReload(t *testing.T) {
+	dir := t.TempDir()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	dotenv := "APP_LOGGER_LEVEL=\"debug\"\nAPP_SERVER_ALLOW_ORIGINS=\"https://dotenv.example.com\"\n"
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte(dotenv), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	path := writeConfig(t, "config.yaml", _yamlConfig)
+
+	sut, err := app.LoadConfig(context.Background(), path)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "info", sut.Logger.Level)
+
+	edited := strings.NewReplacer(
+		"level: info", "level: error",
+		"https://*.example.com", "https://app.example.com",
+	).Replace(_yamlConfig)
+
+	if err := os.WriteFile(path, []byte(edited), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	sut, err = app.LoadConfig(context.Background(), path)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "error", sut.Logger.Level)
+	assert.Equal(t, []string{"https://app.example.com"}, sut.Server.Cors.AllowOrigins)
+}
+
+func TestLoadConfigNotValid(t *testing.T) {
+	t.Setenv("APP_TOKENS_REFRESH_KEY", "short")
+	t.Setenv("APP_TOKENS_ACCESS_TTL", "0s")
+	t.Setenv("APP_SERVER_SOCKET", "localhost")
+	t.Setenv("APP_SERVER_ALLOW_ORIGINS", "*")
+	t.Setenv("APP_SERVER_ALLOW_CREDENTIALS", "true")
+	t.Setenv("APP_USERS_DIRECTORY", "http")
+	t.Setenv("APP_USERS_URL", "users.internal")
+	t.Setenv("APP_SERVER_TLS_RELOAD_INTERVAL", "-1s")
+	t.Setenv("APP_SERVER_TRUSTED_PROXIES", "10.0.0.0/33")
+	t.Setenv("APP_TRACING_EXPORTER", "jaeger")
+	t.Setenv("APP_HASH_MODE", "hmac")
+	t.Setenv("APP_HASH_PEPPER", "f3Kx9QmZp2Lw7RtVb8NcY4Hd6JsGa1Ue")
+
+	_, err := app.LoadConfig(context.Background(), writeConfig(t, "config.yaml", _yamlConfig))
+
+	if assert.Error(t, err) {
+		for _, field := range []string{"tokens.refresh_key", "tokens.access_ttl", "server.sockets", "server.cors", "users.url", "server.tls.reload_interval", "server.client_ip", "tracing.exporter", "hash.pepper"} {
+			assert.Contains(t, err.Error(), field)
+		}
+	}
+}
+
+func TestLoadConfigArgon2(t *testing.T) {
+	tcs := []struct {
+		key string
+		env map[string]string
+	}{
+		{
+			key: "Zero time",
+			env: map[string]string{"APP_HASH_ARGON2_TIME": "0"},
+		},
+		{
+			key: "Memory below lanes",
+			env: map[string]string{"APP_HASH_ARGON2_MEMORY": "16", "APP_HASH_ARGON2_PARALLELISM": "4"},
+		},
+		{
+			key: "Memory above max",
+			env: map[string]string{"APP_HASH_ARGON2_MEMORY": "1048576"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.key, func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+
+			_, err := app.LoadConfig(context.Background(), writeConfig(t, "config.yaml", _yamlConfig))
+
+			if assert.Error(t, err, tc.key) {
+				assert.Contains(t, err.Error(), "hash.argon2", tc.key)
+			}
+		})
+	}
+}
+
+func TestLoadConfigSecretFiles(t *testing.T) {
+	t.Setenv("APP_TOKENS_REFRESH_KEY", "")
+	t.Setenv("APP_TOKENS_REFRESH_KEY_FILE", writeConfig(t, "refresh_key", "vhZ35oAnPtqyu2dNvhZ35oAnPtqyu2dN\n"))
+	t.Setenv("APP_HASH_MODE", "hmac")
+	t.Setenv("APP_HASH_PEPPER_FILE", writeConfig(t, "pepper", "f3Kx9QmZp2Lw7RtVb8NcY4Hd6JsGa1Ue"))
+
+	sut, err := app.LoadConfig(context.Background(), writeConfig(t, "config.yaml", _yamlConfig))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "vhZ35oAnPtqyu2dNvhZ35oAnPtqyu2dN", sut.Tokens.RefreshKey)
+	assert.Equal(t, "f3Kx9QmZp2Lw7RtVb8NcY4Hd6JsGa1Ue", sut.Hash.Pepper)
+
+	t.Setenv("APP_HASH_PEPPER_FILE", filepath.Join(t.TempDir(), "missing"))
+
+	_, err = app.LoadConfig(context.Background(), writeConfig(t, "config.yaml", _yamlConfig))
+
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestLoadConfigVault(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/data/auth-service" || r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		w.Write([]byte(`{"data":{"data":{"tokens_access_key":"tm2bVHVQUgDFfw20UGbNOHEmnUjhUSeaRblT123m3RpKqeLpam3ZYPcd2CS2fA1J"},"metadata":{"version":1}}}`))
+	}))
+	defer srv.Close()
+
+	t.Setenv("APP_SECRETS_SOURCE", "vault")
+	t.Setenv("APP_SECRETS_VAULT_ADDR", srv.URL)
+	t.Setenv("APP_SECRETS_VAULT_PATH", "secret/data/auth-service")
+	t.Setenv("APP_SECRETS_VAULT_TOKEN_FILE", writeConfig(t, "token", "token\n"))
+
+	sut, err := app.LoadConfig(context.Background(), writeConfig(t, "config.yaml", _yamlConfig))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "tm2bVHVQUgDFfw20UGbNOHEmnUjhUSeaRblT123m3RpKqeLpam3ZYPcd2CS2fA1J", sut.Tokens.AccessKey)
+	assert.Equal(t, "MFYXyzEeCVX9wbHbpagdDwCWyacwwLb7", sut.Tokens.RefreshKey)
+
+	t.Setenv("APP_SECRETS_VAULT_TOKEN_FILE", "")
+	t.Setenv("APP_SECRETS_VAULT_TOKEN", "other")
+
+	_, err = app.LoadConfig(context.Background(), writeConfig(t, "config.yaml", _yamlConfig))
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,36 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+
+	httpv1 "github.com/v1adhope/auth-service/internal/transports/http/v1"
+)
+
+func refreshCookie(cfg Config) (httpv1.RefreshCookie, error) {
+	rc := cfg.Server.RefreshCookie
+
+	sameSite, ok := map[string]http.SameSite{
+		"strict": http.SameSiteStrictMode,
+		"lax":    http.SameSiteLaxMode,
+		"none":   http.SameSiteNoneMode,
+	}[rc.SameSite]
+	if !ok {
+		return httpv1.RefreshCookie{}, fmt.Errorf("app: cookie: refreshCookie: unknown same site %q", rc.SameSite)
+	}
+
+	// INFO: browsers drop SameSite=None cookies without Secure
+	if sameSite == http.SameSiteNoneMode && rc.Insecure {
+		return httpv1.RefreshCookie{}, fmt.Errorf("app: cookie: refreshCookie: same site none requires a secure cookie")
+	}
+
+	return httpv1.RefreshCookie{
+		Enabled:  rc.Enabled,
+		Name:     rc.Name,
+		Path:     rc.Path,
+		Domain:   rc.Domain,
+		SameSite: sameSite,
+		MaxAge:   cfg.Storage.Ttl,
+		Insecure: rc.Insecure,
+	}, nil
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"strings"
+
+	httpv1 "github.com/v1adhope/auth-service/internal/transports/http/v1"
+)
+
+// INFO: admin is nil when the admin routes share the public policy
+func corsPolicies(cfg Config) (public httpv1.CorsPolicy, admin *httpv1.CorsPolicy, err error) {
+	public = httpv1.CorsPolicy{
+		AllowOrigins:     cfg.Server.Cors.AllowOrigins,
+		AllowMethods:     splitLegacy(cfg.Server.Cors.AllowMethods),
+		AllowHeaders:     splitLegacy(cfg.Server.Cors.AllowHeaders),
+		ExposeHeaders:    cfg.Server.Cors.ExposeHeaders,
+		AllowCredentials: cfg.Server.Cors.AllowCredentials,
+		MaxAge:           cfg.Server.Cors.MaxAge,
+	}
+
+	if err := public.Validate(); err != nil {
+		return httpv1.CorsPolicy{}, nil, err
+	}
+
+	if len(cfg.Server.AdminCors.AllowOrigins) == 0 {
+		return public, nil, nil
+	}
+
+	admin = &httpv1.CorsPolicy{
+		AllowOrigins:     cfg.Server.AdminCors.AllowOrigins,
+		AllowMethods:     cfg.Server.AdminCors.AllowMethods,
+		AllowHeaders:     cfg.Server.AdminCors.AllowHeaders,
+		ExposeHeaders:    cfg.Server.AdminCors.ExposeHeaders,
+		AllowCredentials: cfg.Server.AdminCors.AllowCredentials,
+		MaxAge:           cfg.Server.AdminCors.MaxAge,
+	}
+
+	if err := admin.Validate(); err != nil {
+		return httpv1.CorsPolicy{}, nil, err
+	}
+
+	return public, admin, nil
+}
+
+// INFO: methods and headers used to be colon separated,
+// neither can contain a colon so old values keep working
+func splitLegacy(values []string) []string {
+	split := make([]string, 0, len(values))
+
+	for _, v := range values {
+		split = append(split, strings.Split(v, ":")...)
+	}
+
+	return split
+}
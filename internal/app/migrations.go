@@ -0,0 +1,49 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/v1adhope/auth-service/db"
+)
+
+// INFO: refuses a schema newer than the embedded migrations even when auto migrate is off
+func migrateSchema(connStr string, auto bool) (err error) {
+	m, err := db.NewMigrate(connStr)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		srcErr, dbErr := m.Close()
+		err = errors.Join(err, srcErr, dbErr)
+	}()
+
+	latest, err := db.LatestVersion()
+	if err != nil {
+		return err
+	}
+
+	current, dirty, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return fmt.Errorf("app: migrations: migrateSchema: Version: %w", err)
+	}
+
+	if current > latest {
+		return fmt.Errorf("app: migrations: migrateSchema: schema version %d is newer than supported %d", current, latest)
+	}
+
+	if !auto {
+		return nil
+	}
+
+	if dirty {
+		return fmt.Errorf("app: migrations: migrateSchema: schema version %d is dirty, fix it with migrate force", current)
+	}
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("app: migrations: migrateSchema: Up: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/v1adhope/auth-service/db"
+	"github.com/v1adhope/auth-service/pkg/postgresql"
+)
+
+func schemaProbe(postgres *postgresql.Postgres) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		expected, err := db.LatestVersion()
+		if err != nil {
+			return err
+		}
+
+		version, dirty, err := postgres.SchemaVersion(ctx)
+		if err != nil {
+			return err
+		}
+
+		if dirty || version != int64(expected) {
+			return fmt.Errorf("app: probes: schemaProbe: expected version %d, got %d (dirty: %t)", expected, version, dirty)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,132 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/tokens"
+	httpv1 "github.com/v1adhope/auth-service/internal/transports/http/v1"
+	"github.com/v1adhope/auth-service/pkg/logger"
+)
+
+// INFO: parts of the running app a SIGHUP can change without a restart
+type reloadable struct {
+	log    *logger.Log
+	tokens *tokens.Tokens
+	router *httpv1.Router
+}
+
+// INFO: everything is parsed before anything is swapped, so a rejected reload
+// leaves the running app as it was instead of half applied
+func (r reloadable) apply(cfg Config) error {
+	public, admin, err := corsPolicies(cfg)
+	if err != nil {
+		return err
+	}
+
+	_, accessErr := tokens.ParseAccessKey(cfg.Tokens.AccessKey)
+	_, refreshErr := tokens.ParseRefreshKey(cfg.Tokens.RefreshKey)
+
+	if err := errors.Join(accessErr, refreshErr); err != nil {
+		return err
+	}
+
+	if err := r.tokens.SetKeys(cfg.Tokens.AccessKey, cfg.Tokens.RefreshKey); err != nil {
+		return err
+	}
+
+	if err := r.router.SetCors(public, admin); err != nil {
+		return err
+	}
+
+	r.log.SetLevel(cfg.Logger.Level)
+
+	return nil
+}
+
+// INFO: cfg without the reloadable fields, what is left takes a restart
+func restartOnly(cfg Config) Config {
+	cfg.Logger.Level = ""
+	cfg.Server.Cors = Cors{}
+	cfg.Server.AdminCors = AdminCors{}
+	cfg.Tokens.AccessKey = ""
+	cfg.Tokens.RefreshKey = ""
+
+	return cfg
+}
+
+// INFO: the config the app runs with, SIGHUP reloads and secret refreshes
+// both start from it so neither undoes what the other applied
+type runningConfig struct {
+	mu  sync.Mutex
+	cfg Config
+}
+
+func newRunningConfig(cfg Config) *runningConfig {
+	return &runningConfig{cfg: cfg}
+}
+
+// INFO: f gets the current config and applies the next one, which is kept unless f fails
+func (r *runningConfig) update(f func(cur Config) (Config, error)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := f(r.cfg)
+	if err != nil {
+		return err
+	}
+
+	r.cfg = next
+
+	return nil
+}
+
+// INFO: a config that fails to load or validate is logged and the running one is kept
+func reloadOnHangup(path string, running *runningConfig, r reloadable) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		defer signal.Stop(hup)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-hup:
+			}
+
+			cfg, err := LoadConfig(ctx, path)
+			if err != nil {
+				r.log.Error(ctx, err, "%s", "config reload rejected")
+				continue
+			}
+
+			changed := false
+
+			if err := running.update(func(cur Config) (Config, error) {
+				if err := r.apply(cfg); err != nil {
+					return Config{}, err
+				}
+
+				changed = !reflect.DeepEqual(restartOnly(cur), restartOnly(cfg))
+
+				return cfg, nil
+			}); err != nil {
+				r.log.Error(ctx, err, "%s", "config reload rejected")
+				continue
+			}
+
+			if changed {
+				r.log.Info(ctx, "%s", "config reloaded, changes outside log level, cors and keys need a restart")
+				continue
+			}
+
+			r.log.Info(ctx, "%s", "config reloaded")
+		}
+	}
+}
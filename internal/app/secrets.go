@@ -0,0 +1,169 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/tokens"
+	"github.com/v1adhope/auth-service/pkg/logger"
+	"github.com/v1adhope/auth-service/pkg/vault"
+)
+
+const SecretsVault = "vault"
+
+// INFO: external store of named secrets, fetched as a whole
+type SecretSource interface {
+	Secrets(ctx context.Context) (map[string]string, error)
+}
+
+type secretField struct {
+	// INFO: key in the secret source
+	name  string
+	value *string
+	file  string
+}
+
+func (cfg *Config) secretFields() []secretField {
+	return []secretField{
+		{"tokens_access_key", &cfg.Tokens.AccessKey, cfg.Tokens.AccessKeyFile},
+		{"tokens_refresh_key", &cfg.Tokens.RefreshKey, cfg.Tokens.RefreshKeyFile},
+		{"hash_pepper", &cfg.Hash.Pepper, cfg.Hash.PepperFile},
+		{"postgres_conn_str", &cfg.Postgres.ConnStr, cfg.Postgres.ConnStrFile},
+		{"storage_redis_url", &cfg.Storage.RedisUrl, cfg.Storage.RedisUrlFile},
+		{"users_token", &cfg.Users.Token, cfg.Users.TokenFile},
+	}
+}
+
+// INFO: a *_FILE path wins over the plain value, the secret source over both
+func (cfg *Config) resolveSecrets(ctx context.Context) error {
+	errs := []error{}
+
+	if cfg.Secrets.VaultTokenFile != "" {
+		token, err := readSecretFile(cfg.Secrets.VaultTokenFile)
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		cfg.Secrets.VaultToken = token
+	}
+
+	for _, f := range cfg.secretFields() {
+		if f.file == "" {
+			continue
+		}
+
+		value, err := readSecretFile(f.file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		*f.value = value
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("app: secrets: resolveSecrets:\n%w", errors.Join(errs...))
+	}
+
+	src, err := secretSource(*cfg)
+	if err != nil || src == nil {
+		return err
+	}
+
+	secrets, err := src.Secrets(ctx)
+	if err != nil {
+		return fmt.Errorf("app: secrets: resolveSecrets: %w", err)
+	}
+
+	cfg.applySecrets(secrets)
+
+	return nil
+}
+
+func (cfg *Config) applySecrets(secrets map[string]string) {
+	for _, f := range cfg.secretFields() {
+		if value, ok := secrets[f.name]; ok {
+			*f.value = value
+		}
+	}
+}
+
+// INFO: nil without a configured source
+func secretSource(cfg Config) (SecretSource, error) {
+	switch cfg.Secrets.Source {
+	case "":
+		return nil, nil
+	case SecretsVault:
+		if cfg.Secrets.VaultAddr == "" || cfg.Secrets.VaultPath == "" {
+			return nil, fmt.Errorf("app: secrets: secretSource: vault requires APP_SECRETS_VAULT_ADDR and APP_SECRETS_VAULT_PATH")
+		}
+
+		return vault.New(
+			vault.WithAddr(cfg.Secrets.VaultAddr),
+			vault.WithToken(cfg.Secrets.VaultToken),
+			vault.WithPath(cfg.Secrets.VaultPath),
+			vault.WithNamespace(cfg.Secrets.VaultNamespace),
+		), nil
+	}
+
+	return nil, fmt.Errorf("app: secrets: secretSource: unknown source %q", cfg.Secrets.Source)
+}
+
+// INFO: docker and kubernetes secrets usually end with a newline
+func readSecretFile(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("app: secrets: readSecretFile: %w", err)
+	}
+
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// INFO: rotates the token keys from the source, other secrets are only read on startup.
+// Fetched values are laid over the running config, so keys the source doesn't hold
+// keep what the last reload set. Values that don't pass Validate are logged and skipped
+func refreshSecrets(src SecretSource, interval time.Duration, running *runningConfig, tm *tokens.Tokens, log *logger.Log) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+
+			secrets, err := src.Secrets(ctx)
+			if err != nil {
+				log.Error(ctx, err, "%s", "secrets refresh failed")
+				continue
+			}
+
+			if err := running.update(func(cur Config) (Config, error) {
+				next := cur
+				next.applySecrets(secrets)
+
+				if err := next.Validate(); err != nil {
+					return Config{}, err
+				}
+
+				if next.Tokens.AccessKey == cur.Tokens.AccessKey && next.Tokens.RefreshKey == cur.Tokens.RefreshKey {
+					return cur, nil
+				}
+
+				if err := tm.SetKeys(next.Tokens.AccessKey, next.Tokens.RefreshKey); err != nil {
+					return Config{}, err
+				}
+
+				return next, nil
+			}); err != nil {
+				log.Error(ctx, err, "%s", "secrets refresh rejected")
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/v1adhope/auth-service/internal/services"
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/metrics"
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/repositories"
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/repositories/memory"
+	redisrepo "github.com/v1adhope/auth-service/internal/services/infrastructure/repositories/redis"
+	httpv1 "github.com/v1adhope/auth-service/internal/transports/http/v1"
+	"github.com/v1adhope/auth-service/pkg/logger"
+	"github.com/v1adhope/auth-service/pkg/postgresql"
+	"github.com/v1adhope/auth-service/pkg/redis"
+)
+
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+	StorageRedis    = "redis"
+
+	_memoryEvictionInterval = time.Minute
+)
+
+type storage struct {
+	repo services.AuthRepo
+	// INFO: set by the postgres driver, shared with the user directory
+	postgres *postgresql.Postgres
+	// INFO: readiness probes of the backend
+	probes []httpv1.Option
+	// INFO: background jobs run by the app workers
+	jobs  []func(ctx context.Context) error
+	close func()
+}
+
+func buildStorage(ctx context.Context, cfg Config, log *logger.Log, metrics *metrics.Metrics) (*storage, error) {
+	switch cfg.Storage.Driver {
+	case StoragePostgres:
+		if cfg.Postgres.ConnStr == "" {
+			return nil, fmt.Errorf("app: storage: buildStorage: postgres storage requires APP_POSTGRES_CONN_STR")
+		}
+
+		if err := migrateSchema(cfg.Postgres.ConnStr, cfg.Postgres.AutoMigrate); err != nil {
+			return nil, err
+		}
+
+		postgres, err := postgresql.Build(
+			ctx,
+			postgresql.WithConnStr(cfg.Postgres.ConnStr),
+			postgresql.WithLogger(log.Logger),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		metrics.RegisterPool(postgres.Pool)
+
+		return &storage{
+			repo:     repositories.New(postgres, repositories.WithTtl(cfg.Storage.Ttl)),
+			postgres: postgres,
+			probes: []httpv1.Option{
+				httpv1.WithReadinessProbe("postgres", postgres.Ping),
+				httpv1.WithReadinessProbe("migrations", schemaProbe(postgres)),
+			},
+			close: func() {
+				postgres.Close()
+				log.Info(ctx, "%s", "postgres pool closed")
+			},
+		}, nil
+	case StorageRedis:
+		if cfg.Storage.RedisUrl == "" {
+			return nil, fmt.Errorf("app: storage: buildStorage: redis storage requires APP_STORAGE_REDIS_URL")
+		}
+
+		rdb, err := redis.Build(
+			ctx,
+			redis.WithUrl(cfg.Storage.RedisUrl),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		return &storage{
+			repo: redisrepo.New(rdb, redisrepo.WithTtl(cfg.Storage.Ttl)),
+			probes: []httpv1.Option{
+				httpv1.WithReadinessProbe("redis", rdb.Ping),
+			},
+			close: func() {
+				rdb.Close()
+				log.Info(ctx, "%s", "redis client closed")
+			},
+		}, nil
+	case StorageMemory:
+		repo := memory.New(memory.WithTtl(cfg.Storage.Ttl))
+
+		return &storage{
+			repo: repo,
+			jobs: []func(ctx context.Context) error{
+				func(ctx context.Context) error {
+					return repo.RunEviction(ctx, _memoryEvictionInterval)
+				},
+			},
+			close: func() {},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("app: storage: buildStorage: unknown driver %q", cfg.Storage.Driver)
+}
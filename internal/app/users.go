@@ -0,0 +1,75 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/v1adhope/auth-service/internal/services"
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/repositories"
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/users"
+	httpv1 "github.com/v1adhope/auth-service/internal/transports/http/v1"
+	"github.com/v1adhope/auth-service/pkg/logger"
+	"github.com/v1adhope/auth-service/pkg/postgresql"
+)
+
+const (
+	UsersNone     = "none"
+	UsersPostgres = "postgres"
+	UsersHttp     = "http"
+)
+
+type userDirectory struct {
+	// INFO: nil for the none directory
+	dir    services.UserDirectory
+	probes []httpv1.Option
+	close  func()
+}
+
+// INFO: the postgres directory reads auth_users through the storage pool,
+// other storage drivers get a pool of their own
+func buildUsers(ctx context.Context, cfg Config, log *logger.Log, storage *storage) (*userDirectory, error) {
+	switch cfg.Users.Directory {
+	case UsersNone:
+		return &userDirectory{close: func() {}}, nil
+	case UsersPostgres:
+		if storage.postgres != nil {
+			return &userDirectory{dir: repositories.New(storage.postgres), close: func() {}}, nil
+		}
+
+		if err := migrateSchema(cfg.Postgres.ConnStr, cfg.Postgres.AutoMigrate); err != nil {
+			return nil, err
+		}
+
+		postgres, err := postgresql.Build(
+			ctx,
+			postgresql.WithConnStr(cfg.Postgres.ConnStr),
+			postgresql.WithLogger(log.Logger),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		return &userDirectory{
+			dir: repositories.New(postgres),
+			probes: []httpv1.Option{
+				httpv1.WithReadinessProbe("users", postgres.Ping),
+			},
+			close: func() {
+				postgres.Close()
+				log.Info(ctx, "%s", "users postgres pool closed")
+			},
+		}, nil
+	case UsersHttp:
+		return &userDirectory{
+			dir: users.New(
+				users.WithUrl(cfg.Users.Url),
+				users.WithToken(cfg.Users.Token),
+				users.WithHttpClient(&http.Client{Timeout: cfg.Users.Timeout}),
+			),
+			close: func() {},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("app: users: buildUsers: unknown directory %q", cfg.Users.Directory)
+}
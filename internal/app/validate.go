@@ -0,0 +1,210 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/hash"
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/tokens"
+	"github.com/v1adhope/auth-service/pkg/clientip"
+	"github.com/v1adhope/auth-service/pkg/tracing"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	_maxAccessTtl      = 24 * time.Hour
+	_maxRefreshGrace   = 5 * time.Minute
+	_maxIdempotencyTtl = 7 * 24 * time.Hour
+)
+
+// INFO: semantic checks on top of cleanenv parsing,
+// every problem is reported at once instead of the first one
+func (cfg Config) Validate() error {
+	errs := []error{}
+
+	check := func(ok bool, field, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	if _, err := tokens.ParseAccessKey(cfg.Tokens.AccessKey); err != nil {
+		check(false, "tokens.access_key", "%v", err)
+	}
+
+	if _, err := tokens.ParseRefreshKey(cfg.Tokens.RefreshKey); err != nil {
+		check(false, "tokens.refresh_key", "%v", err)
+	}
+
+	check(
+		cfg.Tokens.AceessTtl > 0 && cfg.Tokens.AceessTtl <= _maxAccessTtl,
+		"tokens.access_ttl", "must be within (0, %s], got %s", _maxAccessTtl, cfg.Tokens.AceessTtl,
+	)
+	check(
+		cfg.Tokens.RefreshGrace >= 0 && cfg.Tokens.RefreshGrace <= _maxRefreshGrace,
+		"tokens.refresh_grace", "must be within [0, %s], got %s", _maxRefreshGrace, cfg.Tokens.RefreshGrace,
+	)
+	check(
+		cfg.Tokens.IdempotencyTtl >= 0 && cfg.Tokens.IdempotencyTtl <= _maxIdempotencyTtl,
+		"tokens.idempotency_ttl", "must be within [0, %s], got %s", _maxIdempotencyTtl, cfg.Tokens.IdempotencyTtl,
+	)
+
+	check(
+		slices.Contains([]string{hash.ModeBcrypt, hash.ModeSha256, hash.ModeHmac, hash.ModeArgon2id}, cfg.Hash.Mode),
+		"hash.mode", "unknown mode %q", cfg.Hash.Mode,
+	)
+	check(
+		cfg.Hash.Mode != hash.ModeHmac || cfg.Hash.Pepper != "",
+		"hash.pepper", "required by the hmac mode",
+	)
+	check(
+		cfg.Hash.Mode != hash.ModeHmac || cfg.Hash.PepperFile != "" || cfg.Secrets.Source == SecretsVault,
+		"hash.pepper", "must come from pepper_file or the secrets source, not the plain config",
+	)
+	check(
+		cfg.Hash.BcryptCost >= bcrypt.MinCost && cfg.Hash.BcryptCost <= bcrypt.MaxCost,
+		"hash.bcrypt_cost", "must be within [%d, %d], got %d", bcrypt.MinCost, bcrypt.MaxCost, cfg.Hash.BcryptCost,
+	)
+
+	if err := cfg.Hash.argon2().Validate(); err != nil {
+		check(false, "hash.argon2", "%v", err)
+	}
+	check(
+		cfg.Hash.argon2().Within(cfg.Hash.argon2Max()),
+		"hash.argon2", "params %+v above max %+v", cfg.Hash.argon2(), cfg.Hash.argon2Max(),
+	)
+
+	check(
+		slices.Contains([]string{StoragePostgres, StorageMemory, StorageRedis}, cfg.Storage.Driver),
+		"storage.driver", "unknown driver %q", cfg.Storage.Driver,
+	)
+	check(
+		cfg.Storage.Driver != StoragePostgres || cfg.Postgres.ConnStr != "",
+		"postgres.conn_str", "required by the postgres storage",
+	)
+	check(
+		cfg.Storage.Driver != StorageRedis || cfg.Storage.RedisUrl != "",
+		"storage.redis_url", "required by the redis storage",
+	)
+	// INFO: a refresh token that expires before its access token is useless
+	check(
+		cfg.Storage.Ttl == 0 || cfg.Storage.Ttl > cfg.Tokens.AceessTtl,
+		"storage.ttl", "must be 0 or longer than tokens.access_ttl, got %s", cfg.Storage.Ttl,
+	)
+
+	check(
+		slices.Contains([]string{"debug", "info", "error"}, cfg.Logger.Level),
+		"logger.level", "must be debug, info or error, got %q", cfg.Logger.Level,
+	)
+	check(
+		cfg.Logger.AccessSampleRate >= 0 && cfg.Logger.AccessSampleRate <= 1,
+		"logger.access_sample_rate", "must be within [0, 1], got %v", cfg.Logger.AccessSampleRate,
+	)
+	check(
+		cfg.Tracing.SampleRatio >= 0 && cfg.Tracing.SampleRatio <= 1,
+		"tracing.sample_ratio", "must be within [0, 1], got %v", cfg.Tracing.SampleRatio,
+	)
+	check(
+		slices.Contains([]string{tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOtlp}, cfg.Tracing.Exporter),
+		"tracing.exporter", "must be none, stdout or otlp, got %q", cfg.Tracing.Exporter,
+	)
+
+	check(
+		slices.Contains([]string{gin.DebugMode, gin.ReleaseMode, gin.TestMode}, cfg.Server.Mode),
+		"server.mode", "must be debug, release or test, got %q", cfg.Server.Mode,
+	)
+
+	for _, socket := range cfg.Server.Sockets {
+		if err := validateSocket(socket); err != nil {
+			check(false, "server.sockets", "%q: %v", socket, err)
+		}
+	}
+
+	_, err := strconv.ParseUint(cfg.Server.SocketMode, 8, 32)
+	check(err == nil, "server.socket_mode", "must be octal, got %q", cfg.Server.SocketMode)
+
+	check(cfg.Server.ShutdownTimeout > 0, "server.shutdown_timeout", "must be positive")
+	check(cfg.Server.WriteTimeout > 0, "server.write_timeout", "must be positive")
+	check(cfg.Server.ReadTimeout > 0, "server.read_timeout", "must be positive")
+	check(cfg.Server.DrainDelay >= 0, "server.drain_delay", "must not be negative")
+	check(
+		(cfg.Server.Tls.CertFile == "") == (cfg.Server.Tls.KeyFile == ""),
+		"server.tls", "cert_file and key_file go together",
+	)
+	check(cfg.Server.Tls.ReloadInterval >= 0, "server.tls.reload_interval", "must not be negative")
+
+	if _, err := clientip.New(
+		clientip.WithMode(cfg.Server.ClientIp.Mode),
+		clientip.WithTrustedProxies(cfg.Server.ClientIp.TrustedProxies),
+	); err != nil {
+		check(false, "server.client_ip", "%v", err)
+	}
+
+	check(
+		slices.Contains([]string{UsersNone, UsersPostgres, UsersHttp}, cfg.Users.Directory),
+		"users.directory", "must be none, postgres or http, got %q", cfg.Users.Directory,
+	)
+	check(
+		cfg.Users.Directory != UsersPostgres || cfg.Storage.Driver == StoragePostgres || cfg.Postgres.ConnStr != "",
+		"postgres.conn_str", "required by the postgres user directory",
+	)
+	if cfg.Users.Directory == UsersHttp {
+		u, err := url.Parse(cfg.Users.Url)
+		check(
+			err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+			"users.url", "must be an absolute http(s) url, got %q", cfg.Users.Url,
+		)
+		check(cfg.Users.Timeout > 0, "users.timeout", "must be positive")
+	}
+
+	if _, err := secretSource(cfg); err != nil {
+		check(false, "secrets.source", "%v", err)
+	}
+	check(cfg.Secrets.RefreshInterval >= 0, "secrets.refresh_interval", "must not be negative")
+
+	if _, err := refreshCookie(cfg); err != nil {
+		check(false, "server.refresh_cookie", "%v", err)
+	}
+
+	if _, _, err := corsPolicies(cfg); err != nil {
+		check(false, "server.cors", "%v", err)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("app: validate: Validate:\n%w", errors.Join(errs...))
+}
+
+// INFO: mirrors the socket forms httpserver listens on
+func validateSocket(socket string) error {
+	switch {
+	case strings.HasPrefix(socket, "unix://"):
+		if strings.TrimPrefix(socket, "unix://") == "" {
+			return fmt.Errorf("empty unix socket path")
+		}
+
+		return nil
+	case strings.HasPrefix(socket, "fd://"):
+		return nil
+	}
+
+	_, port, err := net.SplitHostPort(strings.TrimPrefix(socket, "tcp://"))
+	if err != nil {
+		return err
+	}
+
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return fmt.Errorf("not valid port %q", port)
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package app
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// INFO: background jobs bound to the app lifetime,
+// stopped after the http server and before the whitelist storage
+type workers struct {
+	g      *errgroup.Group
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newWorkers() *workers {
+	ctx, cancel := context.WithCancel(context.Background())
+	g, ctx := errgroup.WithContext(ctx)
+
+	return &workers{g, ctx, cancel}
+}
+
+func (w *workers) Go(f func(ctx context.Context) error) {
+	w.g.Go(func() error {
+		return f(w.ctx)
+	})
+}
+
+func (w *workers) Stop() error {
+	w.cancel()
+
+	return w.g.Wait()
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/v1adhope/auth-service/internal/app"
+)
+
+const usage = `usage: auth-service [--config <file>] <command> [args]
+
+  --config <file>                        yaml or toml config, env vars override it,
+                                         APP_CONFIG_FILE by default
+
+commands:
+  serve                                  run the http server (default)
+  migrate up                             apply all pending migrations
+  migrate down [n]                       roll back n migrations (1 by default)
+  migrate force <version>                set the version and clear the dirty flag
+  migrate version                        print the current version
+  sessions list --user <guid>            list active sessions of a user
+  sessions revoke --user <guid>          revoke every session of a user
+  tokens inspect <jwt>                   print access token claims and verify them
+  keys generate                          print fresh token keys as env lines
+  gc --before <rfc3339|duration>         delete sessions created before the moment
+`
+
+var ErrUsage = errors.New("cli: usage")
+
+func Run(ctx context.Context, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("auth-service", flag.ContinueOnError)
+	fs.SetOutput(out)
+	fs.Usage = func() { fmt.Fprint(out, usage) }
+	configPath := fs.String("config", os.Getenv("APP_CONFIG_FILE"), "yaml or toml config file")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+
+		return usageErr("%v", err)
+	}
+
+	args = fs.Args()
+
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
+
+	cmd, args := args[0], args[1:]
+
+	switch cmd {
+	case "serve":
+		return app.Run(ctx, *configPath)
+	case "migrate":
+		return migrateCmd(ctx, *configPath, args, out)
+	case "sessions":
+		return sessionsCmd(ctx, *configPath, args, out)
+	case "tokens":
+		return tokensCmd(ctx, *configPath, args, out)
+	case "keys":
+		return keysCmd(args, out)
+	case "gc":
+		return gcCmd(ctx, *configPath, args, out)
+	case "help", "-h", "--help":
+		fmt.Fprint(out, usage)
+		return nil
+	}
+
+	return usageErr("unknown command %q", cmd)
+}
+
+func usageErr(format string, args ...any) error {
+	return fmt.Errorf("%w: %s\n\n%s", ErrUsage, fmt.Sprintf(format, args...), usage)
+}
@@ -0,0 +1,81 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/v1adhope/auth-service/internal/cli"
+)
+
+func TestRunUsage(t *testing.T) {
+	tcs := []struct {
+		key   string
+		input []string
+	}{
+		{
+			key:   "Unknown command",
+			input: []string{"some"},
+		},
+		{
+			key:   "Migrate without action",
+			input: []string{"migrate"},
+		},
+		{
+			key:   "Sessions without user",
+			input: []string{"sessions", "list"},
+		},
+		{
+			key:   "Sessions with not valid user",
+			input: []string{"sessions", "revoke", "--user", "11"},
+		},
+		{
+			key:   "Tokens without jwt",
+			input: []string{"tokens", "inspect"},
+		},
+		{
+			key:   "Gc without before",
+			input: []string{"gc"},
+		},
+		{
+			key:   "Gc with negative duration",
+			input: []string{"gc", "--before", "-24h"},
+		},
+		{
+			key:   "Keys unknown action",
+			input: []string{"keys", "rotate"},
+		},
+		{
+			key:   "Unknown flag",
+			input: []string{"--some", "serve"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run("", func(t *testing.T) {
+			sut := cli.Run(context.Background(), tc.input, &bytes.Buffer{})
+
+			assert.ErrorIs(t, sut, cli.ErrUsage, tc.key)
+		})
+	}
+}
+
+func TestRunKeysGenerate(t *testing.T) {
+	out := &bytes.Buffer{}
+
+	err := cli.Run(context.Background(), []string{"keys", "generate"}, out)
+
+	assert.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^APP_TOKENS_ACCESS_KEY="[a-zA-Z0-9]{64}"\nAPP_TOKENS_REFRESH_KEY="[a-zA-Z0-9]{32}"\n$`), out.String())
+}
+
+func TestRunHelp(t *testing.T) {
+	out := &bytes.Buffer{}
+
+	err := cli.Run(context.Background(), []string{"help"}, out)
+
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "usage: auth-service")
+}
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/v1adhope/auth-service/db"
+	"github.com/v1adhope/auth-service/internal/app"
+)
+
+func migrateCmd(ctx context.Context, configPath string, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.SetOutput(out)
+	source := fs.String("source", "", "migrations source url, embedded migrations by default")
+
+	if len(args) == 0 {
+		return usageErr("migrate: missing action")
+	}
+
+	action := args[0]
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return usageErr("migrate: %v", err)
+	}
+
+	cfg, err := app.LoadConfig(ctx, configPath)
+	if err != nil {
+		return err
+	}
+
+	m, err := newMigrate(*source, cfg.Postgres.ConnStr)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	// INFO: lets an interrupted migration finish the current file instead of leaving the schema dirty
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			m.GracefulStop <- true
+		case <-done:
+		}
+	}()
+
+	switch action {
+	case "up":
+		err = m.Up()
+	case "down":
+		n := 1
+
+		if fs.NArg() > 0 {
+			if n, err = strconv.Atoi(fs.Arg(0)); err != nil || n < 1 {
+				return usageErr("migrate down: n must be a positive number")
+			}
+		}
+
+		err = m.Steps(-n)
+	case "force":
+		if fs.NArg() == 0 {
+			return usageErr("migrate force: missing version")
+		}
+
+		v, convErr := strconv.Atoi(fs.Arg(0))
+		if convErr != nil {
+			return usageErr("migrate force: version must be a number")
+		}
+
+		err = m.Force(v)
+	case "version":
+	default:
+		return usageErr("migrate: unknown action %q", action)
+	}
+
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("cli: migrate: migrateCmd: %s: %w", action, err)
+	}
+
+	v, dirty, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return fmt.Errorf("cli: migrate: migrateCmd: Version: %w", err)
+	}
+
+	fmt.Fprintf(out, "version: %d, dirty: %t\n", v, dirty)
+
+	return nil
+}
+
+func newMigrate(source, connStr string) (*migrate.Migrate, error) {
+	if source == "" {
+		return db.NewMigrate(connStr)
+	}
+
+	m, err := migrate.New(source, connStr)
+	if err != nil {
+		return nil, fmt.Errorf("cli: migrate: newMigrate: New: %w", err)
+	}
+
+	return m, nil
+}
@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/v1adhope/auth-service/internal/app"
+	"github.com/v1adhope/auth-service/internal/models"
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/repositories"
+	redisrepo "github.com/v1adhope/auth-service/internal/services/infrastructure/repositories/redis"
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/validator"
+	"github.com/v1adhope/auth-service/pkg/postgresql"
+	"github.com/v1adhope/auth-service/pkg/redis"
+)
+
+func sessionsCmd(ctx context.Context, configPath string, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("sessions", flag.ContinueOnError)
+	fs.SetOutput(out)
+	userId := fs.String("user", "", "user guid")
+
+	if len(args) == 0 {
+		return usageErr("sessions: missing action")
+	}
+
+	action := args[0]
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return usageErr("sessions: %v", err)
+	}
+
+	if err := validator.New().ValidateGuid(*userId); err != nil {
+		return usageErr("sessions: --user must be a guid")
+	}
+
+	repos, closeRepos, err := buildRepos(ctx, configPath)
+	if err != nil {
+		return err
+	}
+	defer closeRepos()
+
+	switch action {
+	case "list":
+		sessions, err := repos.ListSessions(ctx, *userId)
+		if err != nil {
+			return err
+		}
+
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(sessions)
+	case "revoke":
+		n, err := repos.DestroyUserTokens(ctx, *userId)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "revoked: %d\n", n)
+
+		return nil
+	}
+
+	return usageErr("sessions: unknown action %q", action)
+}
+
+func gcCmd(ctx context.Context, configPath string, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("gc", flag.ContinueOnError)
+	fs.SetOutput(out)
+	before := fs.String("before", "", "rfc3339 timestamp or a duration back from now, e.g. 720h")
+
+	if err := fs.Parse(args); err != nil {
+		return usageErr("gc: %v", err)
+	}
+
+	moment, err := parseMoment(*before, time.Now())
+	if err != nil {
+		return usageErr("gc: %v", err)
+	}
+
+	repos, closeRepos, err := buildRepos(ctx, configPath)
+	if err != nil {
+		return err
+	}
+	defer closeRepos()
+
+	n, err := repos.DestroyTokensBefore(ctx, moment)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "deleted: %d\n", n)
+
+	return nil
+}
+
+func parseMoment(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, fmt.Errorf("--before is required")
+	}
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return time.Time{}, fmt.Errorf("--before must be an rfc3339 timestamp or a positive duration")
+	}
+
+	return now.Add(-d), nil
+}
+
+type sessionRepo interface {
+	ListSessions(ctx context.Context, userId string) ([]models.Session, error)
+	DestroyUserTokens(ctx context.Context, userId string) (int64, error)
+	DestroyTokensBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+func buildRepos(ctx context.Context, configPath string) (sessionRepo, func(), error) {
+	cfg, err := app.LoadConfig(ctx, configPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch cfg.Storage.Driver {
+	case app.StoragePostgres:
+		postgres, err := postgresql.Build(
+			ctx,
+			postgresql.WithConnStr(cfg.Postgres.ConnStr),
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return repositories.New(postgres, repositories.WithTtl(cfg.Storage.Ttl)), postgres.Close, nil
+	case app.StorageRedis:
+		rdb, err := redis.Build(
+			ctx,
+			redis.WithUrl(cfg.Storage.RedisUrl),
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return redisrepo.New(rdb, redisrepo.WithTtl(cfg.Storage.Ttl)), rdb.Close, nil
+	case app.StorageMemory:
+		return nil, nil, fmt.Errorf("cli: sessions: buildRepos: memory storage lives in the serving process")
+	}
+
+	return nil, nil, fmt.Errorf("cli: sessions: buildRepos: unknown storage driver %q", cfg.Storage.Driver)
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/v1adhope/auth-service/internal/app"
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/tokens"
+)
+
+const (
+	_keyAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+	// INFO: HS512 wants at least its block size, AES-256 exactly 32 bytes
+	_accessKeyLen  = 64
+	_refreshKeyLen = 32
+)
+
+func tokensCmd(ctx context.Context, configPath string, args []string, out io.Writer) error {
+	if len(args) != 2 || args[0] != "inspect" {
+		return usageErr("tokens: expected inspect <jwt>")
+	}
+
+	cfg, err := app.LoadConfig(ctx, configPath)
+	if err != nil {
+		return err
+	}
+
+	tokenManager, err := tokens.New(
+		tokens.WithAccessKey(cfg.Tokens.AccessKey),
+		tokens.WithRefreshKey(cfg.Tokens.RefreshKey),
+		tokens.WithIssuer(cfg.Tokens.Issuer),
+	)
+	if err != nil {
+		return err
+	}
+
+	claims, verifyErr := tokenManager.InspectAccess(args[1])
+	if claims == nil {
+		return verifyErr
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(claims); err != nil {
+		return fmt.Errorf("cli: tokens: tokensCmd: Encode: %w", err)
+	}
+
+	if verifyErr != nil {
+		fmt.Fprintf(out, "valid: false (%v)\n", verifyErr)
+		return nil
+	}
+
+	fmt.Fprintln(out, "valid: true")
+
+	return nil
+}
+
+func keysCmd(args []string, out io.Writer) error {
+	if len(args) != 1 || args[0] != "generate" {
+		return usageErr("keys: expected generate")
+	}
+
+	accessKey, err := randomKey(_accessKeyLen)
+	if err != nil {
+		return err
+	}
+
+	refreshKey, err := randomKey(_refreshKeyLen)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "APP_TOKENS_ACCESS_KEY=%q\nAPP_TOKENS_REFRESH_KEY=%q\n", accessKey, refreshKey)
+
+	return nil
+}
+
+func randomKey(n int) (string, error) {
+	key := make([]byte, n)
+	max := big.NewInt(int64(len(_keyAlphabet)))
+
+	for i := range key {
+		idx, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("cli: tokens: randomKey: Int: %w", err)
+		}
+
+		key[i] = _keyAlphabet[idx.Int64()]
+	}
+
+	return string(key), nil
+}
@@ -1,7 +1,48 @@
 package models
 
+import "time"
+
 type TokenPair struct {
 	Id      string `json:"-"`
 	Access  string `json:"accessToken"`
 	Refresh string `json:"refreshToken"`
 }
+
+type Session struct {
+	Id        string    `json:"id"`
+	UserId    string    `json:"userId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// INFO: successor of a rotated refresh token kept for a short while,
+// Token is the hash of the rotated refresh token and Successor the pair
+// sealed with a key only the rotated refresh token derives
+type Grace struct {
+	Token     string
+	Successor string
+	ExpiresAt time.Time
+}
+
+// INFO: issuance stored under a client supplied key, Key is a hash of the client key
+// and Response the issued pair sealed with a key only the client key derives
+type Idempotency struct {
+	Key         string
+	RequestHash string
+	SessionId   string
+	Response    string
+	ExpiresAt   time.Time
+}
+
+const (
+	UserActive   = "active"
+	UserDisabled = "disabled"
+	UserLocked   = "locked"
+)
+
+// INFO: as known to the user directory, only UserActive users get tokens
+type User struct {
+	Id     string   `json:"id"`
+	Status string   `json:"status"`
+	Email  string   `json:"email"`
+	Roles  []string `json:"roles"`
+}
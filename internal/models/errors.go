@@ -5,4 +5,10 @@ import "errors"
 var (
 	ErrNotValidGuid   = errors.New("Not valid guid")
 	ErrNotValidTokens = errors.New("Not valid token(s)")
+
+	ErrNotValidIdempotencyKey = errors.New("Not valid idempotency key")
+	ErrIdempotencyMismatch    = errors.New("Idempotency key reused for another request")
+
+	ErrUserNotFound  = errors.New("User not found")
+	ErrUserNotActive = errors.New("User is disabled or locked")
 )
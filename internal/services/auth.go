@@ -2,76 +2,338 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/v1adhope/auth-service/internal/models"
+	"github.com/v1adhope/auth-service/pkg/logger"
+	"github.com/v1adhope/auth-service/pkg/tracing"
+	"go.opentelemetry.io/otel"
 )
 
-func (s *Services) GenerateTokenPair(ctx context.Context, userId string, ip string) (models.TokenPair, error) {
+var tracer = otel.Tracer("github.com/v1adhope/auth-service/internal/services")
+
+func (s *Services) GenerateTokenPair(ctx context.Context, userId string, ip string) (_ models.TokenPair, err error) {
+	ctx, span := tracer.Start(ctx, "services.GenerateTokenPair")
+	defer func() { tracing.End(span, err) }()
+
 	if err := s.Validator.ValidateGuid(userId); err != nil {
 		return models.TokenPair{}, err
 	}
 
-	tp, err := s.TokenManager.GeneratePair(ip, userId)
-	if err != nil {
+	logger.SetUserId(ctx, userId)
+
+	if _, err := s.checkUser(ctx, userId); err != nil {
 		return models.TokenPair{}, err
 	}
 
-	storeT, err := s.Hash.Do(tp.Refresh)
+	tp, storeT, err := s.issue(ctx, userId, ip)
 	if err != nil {
 		return models.TokenPair{}, err
 	}
 
-	if err := s.AuthRepo.StoreToken(ctx, tp.Id, storeT, time.Now()); err != nil {
+	if err := s.AuthRepo.StoreToken(ctx, tp.Id, userId, storeT, time.Now()); err != nil {
 		return models.TokenPair{}, err
 	}
 
-	tp.Refresh = EncodeBase64(tp.Refresh)
+	s.Meter.TokenIssued()
 
 	return tp, nil
 }
 
-func (s *Services) RefreshTokenPair(ctx context.Context, tp models.TokenPair, ip string) (models.TokenPair, error) {
-	var err error
+// INFO: retries with the same key and request hash get the pair of the first call,
+// the same key with another request hash fails with ErrIdempotencyMismatch
+func (s *Services) GenerateTokenPairIdempotent(ctx context.Context, key, requestHash, userId, ip string) (_ models.TokenPair, err error) {
+	if s.IdempotencyTtl <= 0 {
+		return s.GenerateTokenPair(ctx, userId, ip)
+	}
 
-	tp.Refresh, err = DecodeBase64(tp.Refresh)
-	if err != nil {
+	ctx, span := tracer.Start(ctx, "services.GenerateTokenPairIdempotent")
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.Validator.ValidateGuid(userId); err != nil {
+		return models.TokenPair{}, err
+	}
+
+	logger.SetUserId(ctx, userId)
+
+	if _, err := s.checkUser(ctx, userId); err != nil {
+		return models.TokenPair{}, err
+	}
+
+	keyHash := idempotencyKeyHash(userId, key)
+
+	// INFO: a retry is answered from the record without minting a pair,
+	// the store below still settles requests racing on the same key
+	existing, err := s.AuthRepo.GetIdempotency(ctx, keyHash)
+	switch {
+	case err == nil:
+		return s.replayIdempotent(ctx, key, requestHash, existing)
+	case !errors.Is(err, models.ErrNotValidTokens):
 		return models.TokenPair{}, err
 	}
 
-	tp.Id, err = s.TokenManager.ExtractRefreshPayload(tp.Refresh)
+	tp, storeT, err := s.issue(ctx, userId, ip)
 	if err != nil {
 		return models.TokenPair{}, err
 	}
 
-	storeT, err := s.AuthRepo.GetToken(ctx, tp.Id)
+	response, err := sealPair(purposeIdempotency, key, tp)
 	if err != nil {
 		return models.TokenPair{}, err
 	}
 
-	if err := s.Hash.Check(storeT, tp.Refresh); err != nil {
+	now := time.Now()
+
+	existing, stored, err := s.AuthRepo.StoreTokenIdempotent(ctx, tp.Id, userId, storeT, now, models.Idempotency{
+		Key:         keyHash,
+		RequestHash: requestHash,
+		SessionId:   tp.Id,
+		Response:    response,
+		ExpiresAt:   now.Add(s.IdempotencyTtl),
+	})
+	if err != nil {
 		return models.TokenPair{}, err
 	}
 
-	idAccessT, ipAccessT, userId, err := s.TokenManager.ExtractAccessPayload(tp.Access)
+	if stored {
+		s.Meter.TokenIssued()
+
+		return tp, nil
+	}
+
+	return s.replayIdempotent(ctx, key, requestHash, existing)
+}
+
+func (s *Services) replayIdempotent(ctx context.Context, key, requestHash string, existing models.Idempotency) (models.TokenPair, error) {
+	if existing.RequestHash != requestHash {
+		return models.TokenPair{}, fmt.Errorf("services: auth: GenerateTokenPairIdempotent: %w", models.ErrIdempotencyMismatch)
+	}
+
+	replayed, err := openPair(purposeIdempotency, key, existing.Response)
 	if err != nil {
 		return models.TokenPair{}, err
 	}
 
+	logger.SetSessionId(ctx, replayed.Id)
+
+	s.Meter.TokenReplayed()
+
+	return replayed, nil
+}
+
+// INFO: returns the pair with an encoded refresh token and its hash for the whitelist
+func (s *Services) issue(ctx context.Context, userId, ip string) (models.TokenPair, string, error) {
+	tp, err := s.TokenManager.GeneratePair(ctx, ip, userId)
+	if err != nil {
+		return models.TokenPair{}, "", err
+	}
+
+	logger.SetSessionId(ctx, tp.Id)
+
+	storeT, err := s.Hash.Do(ctx, tp.Refresh)
+	if err != nil {
+		return models.TokenPair{}, "", err
+	}
+
+	tp.Refresh = EncodeRefresh(tp.Refresh)
+
+	return tp, storeT, nil
+}
+
+func (s *Services) RefreshTokenPair(ctx context.Context, tp models.TokenPair, ip string) (_ models.TokenPair, err error) {
+	ctx, span := tracer.Start(ctx, "services.RefreshTokenPair")
+	defer func() { tracing.End(span, err) }()
+
+	tp.Refresh, err = DecodeRefresh(tp.Refresh)
+	if err != nil {
+		return s.refreshFailed(reasonMalformed, err)
+	}
+
+	tp.Id, err = s.TokenManager.ExtractRefreshPayload(ctx, tp.Refresh)
+	if err != nil {
+		return s.refreshFailed(reasonMalformed, err)
+	}
+
+	logger.SetSessionId(ctx, tp.Id)
+
+	storeT, err := s.AuthRepo.GetToken(ctx, tp.Id)
+	if err != nil {
+		if errors.Is(err, models.ErrNotValidTokens) {
+			return s.replay(ctx, tp, ip, err)
+		}
+
+		return s.refreshFailed(reasonInternal, fmt.Errorf("services: auth: RefreshTokenPair: GetToken: %w", err))
+	}
+
+	if err := s.Hash.Check(ctx, storeT, tp.Refresh); err != nil {
+		return s.refreshFailed(reasonHashMismatch, err)
+	}
+
+	userId, reason, err := s.checkAccess(ctx, tp, ip)
+	if err != nil {
+		return s.refreshFailed(reason, err)
+	}
+
+	newTp, newStoreT, err := s.issue(ctx, userId, ip)
+	if err != nil {
+		return s.refreshFailed(reasonInternal, err)
+	}
+
+	now := time.Now()
+	grace := models.Grace{}
+
+	if s.RefreshGrace > 0 {
+		successor, err := sealPair(purposeGrace, tp.Refresh, newTp)
+		if err != nil {
+			return s.refreshFailed(reasonInternal, err)
+		}
+
+		// INFO: the old token lives on in the grace, which is kept in the current scheme
+		graceT := storeT
+		if s.Hash.NeedsRehash(storeT) {
+			graceT, err = s.Hash.Do(ctx, tp.Refresh)
+			if err != nil {
+				return s.refreshFailed(reasonInternal, err)
+			}
+		}
+
+		grace = models.Grace{
+			Token:     graceT,
+			Successor: successor,
+			ExpiresAt: now.Add(s.RefreshGrace),
+		}
+	}
+
+	// INFO: a concurrent refresh with the same token may have rotated it
+	// since GetToken, only one of them wins here
+	if err := s.AuthRepo.RotateToken(ctx, tp.Id, storeT, newTp.Id, userId, newStoreT, now, grace); err != nil {
+		if errors.Is(err, models.ErrNotValidTokens) {
+			return s.replay(ctx, tp, ip, err)
+		}
+
+		return s.refreshFailed(reasonInternal, err)
+	}
+
+	s.Meter.TokenRevoked()
+	s.Meter.TokenIssued()
+	s.Meter.TokenRefreshed()
+
+	return newTp, nil
+}
+
+// INFO: a refresh token rotated less than RefreshGrace ago gets the same
+// successor it was rotated to, anything else is reported as not whitelisted,
+// storage failures stay internal
+func (s *Services) replay(ctx context.Context, tp models.TokenPair, ip string, cause error) (models.TokenPair, error) {
+	if s.RefreshGrace <= 0 {
+		return s.refreshFailed(reasonNotWhitelisted, cause)
+	}
+
+	grace, err := s.AuthRepo.GetGrace(ctx, tp.Id)
+	if err != nil {
+		if errors.Is(err, models.ErrNotValidTokens) {
+			return s.refreshFailed(reasonNotWhitelisted, cause)
+		}
+
+		return s.refreshFailed(reasonInternal, fmt.Errorf("services: auth: replay: GetGrace: %w", err))
+	}
+
+	if err := s.Hash.Check(ctx, grace.Token, tp.Refresh); err != nil {
+		return s.refreshFailed(reasonHashMismatch, err)
+	}
+
+	if _, reason, err := s.checkAccess(ctx, tp, ip); err != nil {
+		return s.refreshFailed(reason, err)
+	}
+
+	successor, err := openPair(purposeGrace, tp.Refresh, grace.Successor)
+	if err != nil {
+		return s.refreshFailed(reasonInternal, err)
+	}
+
+	logger.SetSessionId(ctx, successor.Id)
+
+	s.Meter.TokenReplayed()
+
+	return successor, nil
+}
+
+// INFO: the access token has to belong to the refresh token,
+// returns the failure reason along with the error
+func (s *Services) checkAccess(ctx context.Context, tp models.TokenPair, ip string) (userId, reason string, err error) {
+	idAccessT, ipAccessT, userId, err := s.TokenManager.ExtractAccessPayload(ctx, tp.Access)
+	if err != nil {
+		return "", reasonNotValidAccess, err
+	}
+
+	logger.SetUserId(ctx, userId)
+
 	if idAccessT != tp.Id {
-		return models.TokenPair{}, fmt.Errorf("services: auth: RefreshTokenPair: not equal ids: %w", models.ErrNotValidTokens)
+		return "", reasonPairMismatch, fmt.Errorf("services: auth: checkAccess: not equal ids: %w", models.ErrNotValidTokens)
+	}
+
+	user, err := s.checkUser(ctx, userId)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) || errors.Is(err, models.ErrUserNotActive) {
+			return "", reasonUserRejected, err
+		}
+
+		return "", reasonInternal, err
 	}
 
 	if ip != ipAccessT {
-		if err := s.Alert.Do("<SOME_EMAIL>", "<SOME_MSG>"); err != nil {
-			return models.TokenPair{}, err
+		email := user.Email
+		if email == "" {
+			email = "<SOME_EMAIL>"
+		}
+
+		if err := s.Alert.Do(email, "<SOME_MSG>"); err != nil {
+			return "", reasonInternal, err
+		}
+
+		s.Meter.IpChanged()
+	}
+
+	return userId, "", nil
+}
+
+// INFO: unknown, disabled and locked users are refused, without
+// a directory every user is taken as active and without an email
+func (s *Services) checkUser(ctx context.Context, userId string) (models.User, error) {
+	if s.Users == nil {
+		return models.User{Id: userId, Status: models.UserActive}, nil
+	}
+
+	user, err := s.Users.GetUser(ctx, userId)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return models.User{}, fmt.Errorf("services: auth: checkUser: %w", models.ErrUserNotFound)
 		}
+
+		return models.User{}, err
 	}
 
-	if err := s.AuthRepo.DestroyToken(ctx, tp.Id); err != nil {
-		return models.TokenPair{}, err
+	if user.Status != models.UserActive {
+		return models.User{}, fmt.Errorf("services: auth: checkUser: status %q: %w", user.Status, models.ErrUserNotActive)
 	}
 
-	return s.GenerateTokenPair(ctx, userId, ip)
+	return user, nil
+}
+
+const (
+	reasonMalformed      = "malformed"
+	reasonNotWhitelisted = "not_whitelisted"
+	reasonHashMismatch   = "hash_mismatch"
+	reasonNotValidAccess = "not_valid_access"
+	reasonPairMismatch   = "pair_mismatch"
+	reasonUserRejected   = "user_rejected"
+	reasonInternal       = "internal"
+)
+
+func (s *Services) refreshFailed(reason string, err error) (models.TokenPair, error) {
+	s.Meter.RefreshFailed(reason)
+
+	return models.TokenPair{}, err
 }
@@ -0,0 +1,508 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/v1adhope/auth-service/internal/models"
+	"github.com/v1adhope/auth-service/internal/services"
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/alert"
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/hash"
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/metrics"
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/repositories/memory"
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/tokens"
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/validator"
+	"github.com/v1adhope/auth-service/internal/testhelpers"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func setUp(opts ...services.Option) *services.Services {
+	return setUpWith(hash.New(), memory.New(), opts...)
+}
+
+func setUpWith(h services.Hasher, repo services.AuthRepo, opts ...services.Option) *services.Services {
+	tm, err := tokens.New(
+		tokens.WithAccessKey("sOXgK2jwm7otKu2iq8uy8PN47DZ88T4EodChguRAZ5gUyifoHKU0u63BGlKnCQcW"),
+		tokens.WithAccessTtl(time.Minute),
+		tokens.WithRefreshKey("HC2fAkS4Lyfisrt4agCZgRU7eWPpFgbH"),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	return services.New(
+		validator.New(),
+		tm,
+		h,
+		repo,
+		alert.New(),
+		metrics.New(),
+		opts...,
+	)
+}
+
+func spanNames(spans tracetest.SpanStubs) []string {
+	names := make([]string, 0, len(spans))
+
+	for _, s := range spans {
+		names = append(names, s.Name)
+	}
+
+	return names
+}
+
+func TestTracingRefreshTokenPair(t *testing.T) {
+	recorder := testhelpers.RecordSpans()
+	s := setUp()
+	ctx := context.Background()
+
+	tp, err := s.GenerateTokenPair(ctx, "adb21fec-7892-416a-bbfc-9b2d77e8db4a", "127.0.0.1")
+
+	assert.NoError(t, err)
+
+	_, err = s.RefreshTokenPair(ctx, tp, "127.0.0.1")
+
+	assert.NoError(t, err)
+
+	spans := recorder.GetSpans()
+	names := spanNames(spans)
+
+	for _, expected := range []string{
+		"services.GenerateTokenPair",
+		"services.RefreshTokenPair",
+		"tokens.GeneratePair",
+		"tokens.ExtractRefreshPayload",
+		"tokens.ExtractAccessPayload",
+		"hash.Do",
+		"hash.Check",
+	} {
+		assert.Contains(t, names, expected)
+	}
+
+	// INFO: first issuance ends 3 spans, everything after them belongs to the refresh trace
+	refresh := spans[len(spans)-1]
+
+	assert.Equal(t, "services.RefreshTokenPair", refresh.Name)
+	assert.NotEqual(t, spans[2].SpanContext.TraceID(), refresh.SpanContext.TraceID())
+
+	for _, span := range spans[3 : len(spans)-1] {
+		assert.Equal(t, refresh.SpanContext.TraceID(), span.SpanContext.TraceID(), span.Name)
+	}
+}
+
+func TestTracingRecordsErrors(t *testing.T) {
+	recorder := testhelpers.RecordSpans()
+	s := setUp()
+
+	_, err := s.GenerateTokenPair(context.Background(), "11", "127.0.0.1")
+
+	assert.Error(t, err)
+
+	spans := recorder.GetSpans()
+
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "services.GenerateTokenPair", spans[0].Name)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+}
+
+func TestConcurrentRefresh(t *testing.T) {
+	const n = 8
+
+	s := setUp()
+	ctx := context.Background()
+
+	tp, err := s.GenerateTokenPair(ctx, "adb21fec-7892-416a-bbfc-9b2d77e8db4a", "127.0.0.1")
+
+	assert.NoError(t, err)
+
+	errs := make(chan error, n)
+
+	for range n {
+		go func() {
+			_, err := s.RefreshTokenPair(context.Background(), tp, "127.0.0.1")
+			errs <- err
+		}()
+	}
+
+	won := 0
+
+	for range n {
+		err := <-errs
+		if err == nil {
+			won++
+			continue
+		}
+
+		assert.ErrorIs(t, err, models.ErrNotValidTokens)
+	}
+
+	assert.Equal(t, 1, won)
+}
+
+func TestRefreshGrace(t *testing.T) {
+	s := setUp(services.WithRefreshGrace(time.Minute))
+	ctx := context.Background()
+
+	tp, err := s.GenerateTokenPair(ctx, "adb21fec-7892-416a-bbfc-9b2d77e8db4a", "127.0.0.1")
+
+	assert.NoError(t, err)
+
+	successor, err := s.RefreshTokenPair(ctx, tp, "127.0.0.1")
+
+	assert.NoError(t, err)
+
+	replayed, err := s.RefreshTokenPair(ctx, tp, "127.0.0.1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, successor, replayed)
+
+	_, err = s.RefreshTokenPair(ctx, successor, "127.0.0.1")
+
+	assert.NoError(t, err)
+}
+
+func TestRefreshGraceNotValid(t *testing.T) {
+	tcs := []struct {
+		key  string
+		opts []services.Option
+		pair func(tp, other models.TokenPair) models.TokenPair
+	}{
+		{
+			key: "Grace disabled",
+			pair: func(tp, other models.TokenPair) models.TokenPair {
+				return tp
+			},
+		},
+		{
+			key:  "Access of another pair",
+			opts: []services.Option{services.WithRefreshGrace(time.Minute)},
+			pair: func(tp, other models.TokenPair) models.TokenPair {
+				tp.Access = other.Access
+				return tp
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.key, func(t *testing.T) {
+			s := setUp(tc.opts...)
+			ctx := context.Background()
+
+			tp, err := s.GenerateTokenPair(ctx, "adb21fec-7892-416a-bbfc-9b2d77e8db4a", "127.0.0.1")
+			assert.NoError(t, err)
+
+			other, err := s.GenerateTokenPair(ctx, "adb21fec-7892-416a-bbfc-9b2d77e8db4a", "127.0.0.1")
+			assert.NoError(t, err)
+
+			_, err = s.RefreshTokenPair(ctx, tp, "127.0.0.1")
+			assert.NoError(t, err)
+
+			_, err = s.RefreshTokenPair(ctx, tc.pair(tp, other), "127.0.0.1")
+
+			assert.ErrorIs(t, err, models.ErrNotValidTokens)
+		})
+	}
+}
+
+func TestConcurrentRefreshWithGrace(t *testing.T) {
+	const n = 8
+
+	s := setUp(services.WithRefreshGrace(time.Minute))
+	ctx := context.Background()
+
+	tp, err := s.GenerateTokenPair(ctx, "adb21fec-7892-416a-bbfc-9b2d77e8db4a", "127.0.0.1")
+
+	assert.NoError(t, err)
+
+	pairs := make(chan models.TokenPair, n)
+
+	for range n {
+		go func() {
+			successor, err := s.RefreshTokenPair(context.Background(), tp, "127.0.0.1")
+			assert.NoError(t, err)
+			pairs <- successor
+		}()
+	}
+
+	first := <-pairs
+
+	for range n - 1 {
+		assert.Equal(t, first, <-pairs)
+	}
+}
+
+func TestGenerateTokenPairIdempotent(t *testing.T) {
+	s := setUp(services.WithIdempotencyTtl(time.Minute))
+	ctx := context.Background()
+	userId := "adb21fec-7892-416a-bbfc-9b2d77e8db4a"
+
+	tp, err := s.GenerateTokenPairIdempotent(ctx, "key", "hash", userId, "127.0.0.1")
+
+	assert.NoError(t, err)
+
+	replayed, err := s.GenerateTokenPairIdempotent(ctx, "key", "hash", userId, "127.0.0.1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, tp, replayed)
+
+	_, err = s.GenerateTokenPairIdempotent(ctx, "key", "other", userId, "127.0.0.1")
+
+	assert.ErrorIs(t, err, models.ErrIdempotencyMismatch)
+
+	other, err := s.GenerateTokenPairIdempotent(ctx, "other", "hash", userId, "127.0.0.1")
+
+	assert.NoError(t, err)
+	assert.NotEqual(t, tp, other)
+
+	_, err = s.RefreshTokenPair(ctx, replayed, "127.0.0.1")
+
+	assert.NoError(t, err)
+}
+
+func TestGenerateTokenPairIdempotentDisabled(t *testing.T) {
+	s := setUp()
+	ctx := context.Background()
+	userId := "adb21fec-7892-416a-bbfc-9b2d77e8db4a"
+
+	tp, err := s.GenerateTokenPairIdempotent(ctx, "key", "hash", userId, "127.0.0.1")
+
+	assert.NoError(t, err)
+
+	other, err := s.GenerateTokenPairIdempotent(ctx, "key", "hash", userId, "127.0.0.1")
+
+	assert.NoError(t, err)
+	assert.NotEqual(t, tp, other)
+}
+
+// INFO: refresh tokens with an opaque tail past bcrypt's 72 bytes
+type longTokens struct {
+	services.TokenManager
+}
+
+const _longTail = 128
+
+func (lt longTokens) GeneratePair(ctx context.Context, ip string, userId string) (models.TokenPair, error) {
+	tp, err := lt.TokenManager.GeneratePair(ctx, ip, userId)
+	tp.Refresh += strings.Repeat("t", _longTail)
+
+	return tp, err
+}
+
+func (lt longTokens) ExtractRefreshPayload(ctx context.Context, token string) (string, error) {
+	if len(token) < _longTail {
+		return "", models.ErrNotValidTokens
+	}
+
+	return lt.TokenManager.ExtractRefreshPayload(ctx, token[:len(token)-_longTail])
+}
+
+func TestRefreshLongTokens(t *testing.T) {
+	s := setUp()
+	s.TokenManager = longTokens{s.TokenManager}
+	ctx := context.Background()
+
+	tp, err := s.GenerateTokenPair(ctx, "adb21fec-7892-416a-bbfc-9b2d77e8db4a", "127.0.0.1")
+
+	assert.NoError(t, err)
+
+	refresh, err := services.DecodeRefresh(tp.Refresh)
+
+	assert.NoError(t, err)
+
+	forged := tp
+	forged.Refresh = services.EncodeRefresh(refresh[:len(refresh)-1] + "f")
+
+	_, err = s.RefreshTokenPair(ctx, forged, "127.0.0.1")
+
+	assert.ErrorIs(t, err, models.ErrNotValidTokens)
+
+	_, err = s.RefreshTokenPair(ctx, tp, "127.0.0.1")
+
+	assert.NoError(t, err)
+}
+
+func TestRefreshLegacyFormat(t *testing.T) {
+	s := setUp()
+	ctx := context.Background()
+
+	tp, err := s.GenerateTokenPair(ctx, "adb21fec-7892-416a-bbfc-9b2d77e8db4a", "127.0.0.1")
+
+	assert.NoError(t, err)
+
+	refresh, err := services.DecodeRefresh(tp.Refresh)
+
+	assert.NoError(t, err)
+
+	tp.Refresh = services.EncodeBase64(refresh)
+
+	sut, err := s.RefreshTokenPair(ctx, tp, "127.0.0.1")
+
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(sut.Refresh, "ast_rt_"))
+}
+
+type directory map[string]models.User
+
+func (d directory) GetUser(ctx context.Context, userId string) (models.User, error) {
+	user, ok := d[userId]
+	if !ok {
+		return models.User{}, models.ErrUserNotFound
+	}
+
+	return user, nil
+}
+
+func TestUserDirectory(t *testing.T) {
+	const userId = "adb21fec-7892-416a-bbfc-9b2d77e8db4a"
+
+	tcs := []struct {
+		key         string
+		user        *models.User
+		expectedErr error
+	}{
+		{
+			key:  "Active",
+			user: &models.User{Id: userId, Status: models.UserActive},
+		},
+		{
+			key:         "Disabled",
+			user:        &models.User{Id: userId, Status: models.UserDisabled},
+			expectedErr: models.ErrUserNotActive,
+		},
+		{
+			key:         "Locked",
+			user:        &models.User{Id: userId, Status: models.UserLocked},
+			expectedErr: models.ErrUserNotActive,
+		},
+		{
+			key:         "Unknown status",
+			user:        &models.User{Id: userId},
+			expectedErr: models.ErrUserNotActive,
+		},
+		{
+			key:         "Unknown",
+			expectedErr: models.ErrUserNotFound,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.key, func(t *testing.T) {
+			users := directory{}
+			s := setUp(services.WithUserDirectory(users), services.WithIdempotencyTtl(time.Minute))
+			ctx := context.Background()
+
+			if tc.user != nil {
+				users[userId] = *tc.user
+			}
+
+			_, err := s.GenerateTokenPair(ctx, userId, "127.0.0.1")
+
+			assert.ErrorIs(t, err, tc.expectedErr, tc.key)
+
+			_, err = s.GenerateTokenPairIdempotent(ctx, "key", "hash", userId, "127.0.0.1")
+
+			assert.ErrorIs(t, err, tc.expectedErr, tc.key)
+
+			// INFO: the user goes away after the pair was issued
+			users[userId] = models.User{Id: userId, Status: models.UserActive}
+
+			tp, err := s.GenerateTokenPair(ctx, userId, "127.0.0.1")
+
+			assert.NoError(t, err, tc.key)
+
+			delete(users, userId)
+
+			if tc.user != nil {
+				users[userId] = *tc.user
+			}
+
+			_, err = s.RefreshTokenPair(ctx, tp, "127.0.0.1")
+
+			assert.ErrorIs(t, err, tc.expectedErr, tc.key)
+		})
+	}
+}
+
+// INFO: the whitelist is there but unreachable
+type unavailableRepo struct {
+	*memory.Repos
+	tokenErr error
+	graceErr error
+}
+
+func (r unavailableRepo) GetToken(ctx context.Context, id string) (string, error) {
+	if r.tokenErr != nil {
+		return "", r.tokenErr
+	}
+
+	return "", fmt.Errorf("unavailableRepo: GetToken: %w", models.ErrNotValidTokens)
+}
+
+func (r unavailableRepo) GetGrace(ctx context.Context, id string) (models.Grace, error) {
+	return models.Grace{}, r.graceErr
+}
+
+func TestRefreshStorageFailure(t *testing.T) {
+	errUnavailable := errors.New("connection refused")
+
+	tcs := []struct {
+		key  string
+		repo unavailableRepo
+	}{
+		{
+			key:  "Token",
+			repo: unavailableRepo{Repos: memory.New(), tokenErr: errUnavailable},
+		},
+		{
+			key:  "Grace",
+			repo: unavailableRepo{Repos: memory.New(), graceErr: errUnavailable},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.key, func(t *testing.T) {
+			s := setUpWith(hash.New(), tc.repo, services.WithRefreshGrace(time.Minute))
+			ctx := context.Background()
+
+			tp, err := s.GenerateTokenPair(ctx, "adb21fec-7892-416a-bbfc-9b2d77e8db4a", "127.0.0.1")
+
+			assert.NoError(t, err, tc.key)
+
+			_, err = s.RefreshTokenPair(ctx, tp, "127.0.0.1")
+
+			assert.ErrorIs(t, err, errUnavailable, tc.key)
+			assert.NotErrorIs(t, err, models.ErrNotValidTokens, tc.key)
+		})
+	}
+}
+
+func TestRefreshRehashesGrace(t *testing.T) {
+	repo := memory.New()
+	ctx := context.Background()
+
+	tp, err := setUpWith(hash.New(hash.WithMode(hash.ModeSha256)), repo).GenerateTokenPair(ctx, "adb21fec-7892-416a-bbfc-9b2d77e8db4a", "127.0.0.1")
+
+	assert.NoError(t, err)
+
+	s := setUpWith(hash.New(hash.WithBcryptCost(4)), repo, services.WithRefreshGrace(time.Minute))
+
+	successor, err := s.RefreshTokenPair(ctx, tp, "127.0.0.1")
+
+	assert.NoError(t, err)
+
+	grace, err := repo.GetGrace(ctx, tp.Id)
+
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(grace.Token, "$bcrypt-sha256$"), grace.Token)
+
+	replayed, err := s.RefreshTokenPair(ctx, tp, "127.0.0.1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, successor, replayed)
+}
@@ -1,11 +1,17 @@
 package services
 
+import "time"
+
 type Services struct {
-	Validator    Validater
-	TokenManager TokenManager
-	Hash         Hasher
-	AuthRepo     AuthRepo
-	Alert        Alerter
+	Validator      Validater
+	TokenManager   TokenManager
+	Hash           Hasher
+	AuthRepo       AuthRepo
+	Alert          Alerter
+	Meter          Meter
+	RefreshGrace   time.Duration
+	IdempotencyTtl time.Duration
+	Users          UserDirectory
 }
 
 func New(
@@ -14,12 +20,20 @@ func New(
 	h Hasher,
 	authR AuthRepo,
 	a Alerter,
+	m Meter,
+	opts ...Option,
 ) *Services {
+	cfg := config(opts...)
+
 	return &Services{
-		Validator:    v,
-		TokenManager: tm,
-		Hash:         h,
-		AuthRepo:     authR,
-		Alert:        a,
+		Validator:      v,
+		TokenManager:   tm,
+		Hash:           h,
+		AuthRepo:       authR,
+		Alert:          a,
+		Meter:          m,
+		RefreshGrace:   cfg.RefreshGrace,
+		IdempotencyTtl: cfg.IdempotencyTtl,
+		Users:          cfg.Users,
 	}
 }
@@ -2,7 +2,10 @@ package services
 
 import (
 	"encoding/base64"
+	"encoding/binary"
 	"fmt"
+	"hash/crc32"
+	"strings"
 
 	"github.com/v1adhope/auth-service/internal/models"
 )
@@ -19,3 +22,54 @@ func DecodeBase64(encodetext string) (string, error) {
 
 	return string(text), nil
 }
+
+const (
+	_refreshPrefix  = "ast_rt_"
+	_refreshVersion = byte(1)
+	_checksumSize   = crc32.Size
+)
+
+// INFO: refresh tokens leave the service as
+// ast_rt_ + base64url(version | payload | crc32(version | payload)),
+// the checksum lets malformed tokens be rejected before any crypto or db work
+func EncodeRefresh(payload string) string {
+	data := make([]byte, 0, 1+len(payload)+_checksumSize)
+	data = append(data, _refreshVersion)
+	data = append(data, payload...)
+	data = binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(data))
+
+	return _refreshPrefix + base64.RawURLEncoding.EncodeToString(data)
+}
+
+// INFO: tokens without the prefix are taken as the legacy padded base64 format
+func DecodeRefresh(token string) (string, error) {
+	if token == "" {
+		return "", fmt.Errorf("services: encoding: DecodeRefresh: empty: %w", models.ErrNotValidTokens)
+	}
+
+	encoded, ok := strings.CutPrefix(token, _refreshPrefix)
+	if !ok {
+		return DecodeBase64(token)
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("services: encoding: DecodeRefresh: DecodeString: %w", models.ErrNotValidTokens)
+	}
+
+	if len(data) <= 1+_checksumSize {
+		return "", fmt.Errorf("services: encoding: DecodeRefresh: too short: %w", models.ErrNotValidTokens)
+	}
+
+	body, sum := data[:len(data)-_checksumSize], data[len(data)-_checksumSize:]
+
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(sum) {
+		return "", fmt.Errorf("services: encoding: DecodeRefresh: checksum mismatch: %w", models.ErrNotValidTokens)
+	}
+
+	if body[0] != _refreshVersion {
+		return "", fmt.Errorf("services: encoding: DecodeRefresh: unknown version %d: %w", body[0], models.ErrNotValidTokens)
+	}
+
+	return string(body[1:]), nil
+}
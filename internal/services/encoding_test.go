@@ -1,9 +1,15 @@
 package services_test
 
 import (
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/v1adhope/auth-service/internal/models"
 	"github.com/v1adhope/auth-service/internal/services"
 )
 
@@ -71,3 +77,67 @@ func TestDecodeBase64(t *testing.T) {
 		})
 	}
 }
+
+func TestEncodeRefresh(t *testing.T) {
+	payloads := []string{
+		"caba6bb4-f3e4-4eea-9d80-6376a6e5ad84",
+		"\xff\xfe\xfd binary ciphertext ?&=/+",
+	}
+
+	for _, payload := range payloads {
+		t.Run("", func(t *testing.T) {
+			token := services.EncodeRefresh(payload)
+
+			assert.True(t, strings.HasPrefix(token, "ast_rt_"))
+			assert.Equal(t, url.QueryEscape(token), token)
+
+			sut, err := services.DecodeRefresh(token)
+
+			assert.NoError(t, err)
+			assert.Equal(t, payload, sut)
+		})
+	}
+}
+
+func TestDecodeRefreshLegacy(t *testing.T) {
+	sut, err := services.DecodeRefresh("Y2FiYTZiYjQtZjNlNC00ZWVhLTlkODAtNjM3NmE2ZTVhZDg0")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "caba6bb4-f3e4-4eea-9d80-6376a6e5ad84", sut)
+}
+
+func rawRefresh(version byte, payload string) string {
+	data := append([]byte{version}, payload...)
+	data = binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(data))
+
+	return "ast_rt_" + base64.RawURLEncoding.EncodeToString(data)
+}
+
+func TestDecodeRefreshNotValid(t *testing.T) {
+	valid := services.EncodeRefresh("caba6bb4-f3e4-4eea-9d80-6376a6e5ad84")
+	last := len(valid) - 1
+	flipped := valid[:last] + map[bool]string{true: "B", false: "A"}[valid[last] == 'A']
+
+	tcs := []struct {
+		key   string
+		input string
+	}{
+		{key: "Empty", input: ""},
+		{key: "Prefix only", input: "ast_rt_"},
+		{key: "Padded", input: valid + "=="},
+		{key: "Standard alphabet", input: "ast_rt_+/+/+/+/+/+/"},
+		{key: "Too short", input: "ast_rt_AQAAAAA"},
+		{key: "Checksum mismatch", input: flipped},
+		{key: "Truncated", input: valid[:last-4]},
+		{key: "Unknown version", input: rawRefresh(2, "caba6bb4-f3e4-4eea-9d80-6376a6e5ad84")},
+		{key: "Legacy not base64", input: "not base64!"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.key, func(t *testing.T) {
+			_, err := services.DecodeRefresh(tc.input)
+
+			assert.ErrorIs(t, err, models.ErrNotValidTokens, tc.key)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package hash
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/v1adhope/auth-service/internal/models"
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	_prefixArgon2id = "$argon2id$"
+
+	_argon2SaltLen = 16
+	_argon2KeyLen  = 32
+)
+
+// INFO: memory in KiB
+type Argon2 struct {
+	Memory      uint32
+	Time        uint32
+	Parallelism uint8
+}
+
+// INFO: argon2.IDKey panics below these bounds
+func (p Argon2) Validate() error {
+	if p.Time == 0 || p.Parallelism == 0 || p.Memory < 8*uint32(p.Parallelism) {
+		return fmt.Errorf("hash: argon2: Validate: time and parallelism must be positive and memory at least 8 KiB per lane, got %+v", p)
+	}
+
+	return nil
+}
+
+// INFO: true when no param of p is above max
+func (p Argon2) Within(max Argon2) bool {
+	return p.Memory <= max.Memory && p.Time <= max.Time && p.Parallelism <= max.Parallelism
+}
+
+// INFO: PHC string format, $argon2id$v=19$m=<memory>,t=<time>,p=<parallelism>$<salt>$<key>
+func doArgon2id(p Argon2, target string) (string, error) {
+	salt := make([]byte, _argon2SaltLen)
+
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("Read: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(target), salt, p.Time, p.Memory, p.Parallelism, _argon2KeyLen)
+
+	return fmt.Sprintf(
+		"%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		_prefixArgon2id,
+		argon2.Version,
+		p.Memory,
+		p.Time,
+		p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// INFO: params come from the storage, a crafted or corrupted row
+// must not make every check allocate more than max allows
+func checkArgon2id(max Argon2, hashedTarget, target string) error {
+	p, salt, key, err := parseArgon2id(hashedTarget)
+	if err != nil {
+		return err
+	}
+
+	if p.Validate() != nil || !p.Within(max) {
+		return fmt.Errorf("checkArgon2id: params %+v out of bounds: %w", p, models.ErrNotValidTokens)
+	}
+
+	other := argon2.IDKey([]byte(target), salt, p.Time, p.Memory, p.Parallelism, uint32(len(key)))
+
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return fmt.Errorf("ConstantTimeCompare: %w", models.ErrNotValidTokens)
+	}
+
+	return nil
+}
+
+func parseArgon2id(hashedTarget string) (p Argon2, salt, key []byte, err error) {
+	parts := strings.Split(strings.TrimPrefix(hashedTarget, _prefixArgon2id), "$")
+	if len(parts) != 4 {
+		return Argon2{}, nil, nil, fmt.Errorf("parseArgon2id: malformed: %w", models.ErrNotValidTokens)
+	}
+
+	version := 0
+
+	if _, err := fmt.Sscanf(parts[0], "v=%d", &version); err != nil || version != argon2.Version {
+		return Argon2{}, nil, nil, fmt.Errorf("parseArgon2id: version: %w", models.ErrNotValidTokens)
+	}
+
+	if _, err := fmt.Sscanf(parts[1], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Parallelism); err != nil {
+		return Argon2{}, nil, nil, fmt.Errorf("parseArgon2id: params: %w", models.ErrNotValidTokens)
+	}
+
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[2]); err != nil {
+		return Argon2{}, nil, nil, fmt.Errorf("parseArgon2id: salt: %w", models.ErrNotValidTokens)
+	}
+
+	if key, err = base64.RawStdEncoding.DecodeString(parts[3]); err != nil || len(key) == 0 {
+		return Argon2{}, nil, nil, fmt.Errorf("parseArgon2id: key: %w", models.ErrNotValidTokens)
+	}
+
+	return p, salt, key, nil
+}
@@ -1,27 +1,159 @@
 package hash
 
 import (
+	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/v1adhope/auth-service/internal/models"
+	"github.com/v1adhope/auth-service/pkg/tracing"
+	"go.opentelemetry.io/otel"
 	"golang.org/x/crypto/bcrypt"
 )
 
-type Hash struct{}
+var tracer = otel.Tracer("github.com/v1adhope/auth-service/internal/services/infrastructure/hash")
 
-func New() *Hash {
-	return &Hash{}
+type Hash struct {
+	observer   Observer
+	mode       string
+	pepper     []byte
+	bcryptCost int
+	argon2     Argon2
+	argon2Max  Argon2
 }
 
-func (h *Hash) Do(target string) (string, error) {
-	hashBytes, err := bcrypt.GenerateFromPassword([]byte(target), bcrypt.DefaultCost)
+// INFO: panic on unknown mode, hmac mode without pepper or out of range costs
+func New(opts ...Option) *Hash {
+	h := &Hash{
+		mode:       ModeBcrypt,
+		bcryptCost: bcrypt.DefaultCost,
+		argon2: Argon2{
+			Memory:      64 * 1024,
+			Time:        3,
+			Parallelism: 4,
+		},
+		argon2Max: DefaultArgon2Max,
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	switch h.mode {
+	case ModeBcrypt, ModeSha256:
+	case ModeHmac:
+		if len(h.pepper) == 0 {
+			panic("hash: define pepper for hmac mode")
+		}
+	case ModeArgon2id:
+		if err := h.argon2.Validate(); err != nil {
+			panic(err.Error())
+		}
+
+		if !h.argon2.Within(h.argon2Max) {
+			panic(fmt.Sprintf("hash: argon2 params %+v above max %+v", h.argon2, h.argon2Max))
+		}
+	default:
+		panic(fmt.Sprintf("hash: unknown mode %q", h.mode))
+	}
+
+	if h.bcryptCost < bcrypt.MinCost || h.bcryptCost > bcrypt.MaxCost {
+		panic(fmt.Sprintf("hash: bcrypt cost must be within %d..%d", bcrypt.MinCost, bcrypt.MaxCost))
+	}
+
+	return h
+}
+
+func (h *Hash) Do(ctx context.Context, target string) (_ string, err error) {
+	_, span := tracer.Start(ctx, "hash.Do")
+	defer func() { tracing.End(span, err) }()
+
+	defer h.observe("do", time.Now())
+
+	switch h.mode {
+	case ModeSha256:
+		return doSha256(target), nil
+	case ModeHmac:
+		return doHmac(h.pepper, target), nil
+	case ModeArgon2id:
+		hashed, err := doArgon2id(h.argon2, target)
+		if err != nil {
+			return "", fmt.Errorf("hash: hash: Do: %w", err)
+		}
+
+		return hashed, nil
+	}
+
+	hashed, err := doBcrypt(h.bcryptCost, target)
+	if err != nil {
+		return "", fmt.Errorf("hash: hash: Do: %w", err)
+	}
+
+	return hashed, nil
+}
+
+func (h *Hash) Check(ctx context.Context, hashedTarget, target string) (err error) {
+	_, span := tracer.Start(ctx, "hash.Check")
+	defer func() { tracing.End(span, err) }()
+
+	defer h.observe("check", time.Now())
+
+	switch {
+	case strings.HasPrefix(hashedTarget, _prefixSha256):
+		err = checkEqual(hashedTarget, doSha256(target))
+	case strings.HasPrefix(hashedTarget, _prefixHmac):
+		if len(h.pepper) == 0 {
+			return fmt.Errorf("hash: hash: Check: hmac hash without pepper: %w", models.ErrNotValidTokens)
+		}
+
+		err = checkEqual(hashedTarget, doHmac(h.pepper, target))
+	case strings.HasPrefix(hashedTarget, _prefixArgon2id):
+		err = checkArgon2id(h.argon2Max, hashedTarget, target)
+	case strings.HasPrefix(hashedTarget, _prefixBcrypt):
+		err = checkBcrypt(hashedTarget, target)
+	case isLegacyBcrypt(hashedTarget):
+		err = checkLegacyBcrypt(hashedTarget, target)
+	default:
+		return fmt.Errorf("hash: hash: Check: unknown scheme: %w", models.ErrNotValidTokens)
+	}
+
 	if err != nil {
-		return "", fmt.Errorf("hash: hash: Do: GenerateFromPassword: %w", models.ErrNotValidTokens)
+		return fmt.Errorf("hash: hash: Check: %w", err)
+	}
+
+	return nil
+}
+
+// INFO: true when hashedTarget was made with another mode or other costs,
+// callers holding the plain secret after a successful Check should store Do of it
+func (h *Hash) NeedsRehash(hashedTarget string) bool {
+	switch h.mode {
+	case ModeSha256:
+		return !strings.HasPrefix(hashedTarget, _prefixSha256)
+	case ModeHmac:
+		return !strings.HasPrefix(hashedTarget, _prefixHmac)
+	case ModeArgon2id:
+		if !strings.HasPrefix(hashedTarget, _prefixArgon2id) {
+			return true
+		}
+
+		p, _, key, err := parseArgon2id(hashedTarget)
+
+		return err != nil || p != h.argon2 || len(key) != _argon2KeyLen
 	}
 
-	return string(hashBytes), nil
+	if !strings.HasPrefix(hashedTarget, _prefixBcrypt) {
+		return true
+	}
+
+	cost, err := bcrypt.Cost([]byte(strings.TrimPrefix(hashedTarget, _prefixBcrypt)))
+
+	return err != nil || cost != h.bcryptCost
 }
 
-func (h *Hash) Check(hashedTarget, target string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedTarget), []byte(target))
+func (h *Hash) observe(op string, start time.Time) {
+	if h.observer != nil {
+		h.observer.ObserveHash(op, time.Since(start))
+	}
 }
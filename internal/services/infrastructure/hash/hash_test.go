@@ -0,0 +1,301 @@
+package hash_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/v1adhope/auth-service/internal/models"
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/hash"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	_target = "ZGVhZGJlZWZkZWFkYmVlZmRlYWRiZWVmZGVhZGJlZWZkZWFkYmVlZg"
+	_pepper = "pepper"
+)
+
+var _argon2 = hash.Argon2{Memory: 1024, Time: 1, Parallelism: 1}
+
+func TestHash(t *testing.T) {
+	tcs := []struct {
+		key            string
+		opts           []hash.Option
+		expectedPrefix string
+	}{
+		{
+			key:            "Bcrypt",
+			expectedPrefix: "$bcrypt-sha256$2a$10$",
+		},
+		{
+			key:            "Sha256",
+			opts:           []hash.Option{hash.WithMode(hash.ModeSha256)},
+			expectedPrefix: "$sha256$",
+		},
+		{
+			key:            "Hmac",
+			opts:           []hash.Option{hash.WithMode(hash.ModeHmac), hash.WithPepper(_pepper)},
+			expectedPrefix: "$hmac-sha256$",
+		},
+		{
+			key:            "Bcrypt with cost",
+			opts:           []hash.Option{hash.WithBcryptCost(4)},
+			expectedPrefix: "$bcrypt-sha256$2a$04$",
+		},
+		{
+			key:            "Argon2id",
+			opts:           []hash.Option{hash.WithMode(hash.ModeArgon2id), hash.WithArgon2(_argon2)},
+			expectedPrefix: "$argon2id$v=19$m=1024,t=1,p=1$",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.key, func(t *testing.T) {
+			ctx := context.Background()
+			sut := hash.New(tc.opts...)
+
+			hashed, err := sut.Do(ctx, _target)
+
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(hashed, tc.expectedPrefix), hashed)
+			assert.NoError(t, sut.Check(ctx, hashed, _target))
+			assert.ErrorIs(t, sut.Check(ctx, hashed, _target+"a"), models.ErrNotValidTokens)
+		})
+	}
+}
+
+func TestSharedPrefix(t *testing.T) {
+	prefix := strings.Repeat("r", 72)
+	target, other := prefix+"target", prefix+"other"
+
+	for key, sut := range map[string]*hash.Hash{
+		"Bcrypt":   hash.New(hash.WithBcryptCost(4)),
+		"Sha256":   hash.New(hash.WithMode(hash.ModeSha256)),
+		"Hmac":     hash.New(hash.WithMode(hash.ModeHmac), hash.WithPepper(_pepper)),
+		"Argon2id": hash.New(hash.WithMode(hash.ModeArgon2id), hash.WithArgon2(_argon2)),
+	} {
+		t.Run(key, func(t *testing.T) {
+			ctx := context.Background()
+
+			hashed, err := sut.Do(ctx, target)
+
+			require.NoError(t, err)
+			assert.NoError(t, sut.Check(ctx, hashed, target))
+			assert.ErrorIs(t, sut.Check(ctx, hashed, other), models.ErrNotValidTokens)
+			assert.ErrorIs(t, sut.Check(ctx, hashed, prefix), models.ErrNotValidTokens)
+		})
+	}
+}
+
+func TestLegacyBcrypt(t *testing.T) {
+	ctx := context.Background()
+	sut := hash.New(hash.WithBcryptCost(4))
+	prefix := strings.Repeat("r", 72)
+
+	legacy, err := bcrypt.GenerateFromPassword([]byte(_target), 4)
+	require.NoError(t, err)
+
+	legacyFull, err := bcrypt.GenerateFromPassword([]byte(prefix), 4)
+	require.NoError(t, err)
+
+	assert.NoError(t, sut.Check(ctx, string(legacy), _target))
+	assert.ErrorIs(t, sut.Check(ctx, string(legacy), _target+"a"), models.ErrNotValidTokens)
+	assert.True(t, sut.NeedsRehash(string(legacy)))
+
+	assert.NoError(t, sut.Check(ctx, string(legacyFull), prefix))
+	assert.ErrorIs(t, sut.Check(ctx, string(legacyFull), prefix+"suffix"), models.ErrNotValidTokens)
+}
+
+func TestCheckAcrossModes(t *testing.T) {
+	ctx := context.Background()
+	sut := hash.New(hash.WithMode(hash.ModeHmac), hash.WithPepper(_pepper))
+
+	for _, h := range []*hash.Hash{
+		hash.New(),
+		hash.New(hash.WithMode(hash.ModeSha256)),
+		hash.New(hash.WithMode(hash.ModeArgon2id), hash.WithArgon2(_argon2)),
+	} {
+		hashed, err := h.Do(ctx, _target)
+
+		require.NoError(t, err)
+		assert.NoError(t, sut.Check(ctx, hashed, _target), hashed)
+	}
+}
+
+func TestCheckNotValid(t *testing.T) {
+	ctx := context.Background()
+
+	hashed, err := hash.New(hash.WithMode(hash.ModeHmac), hash.WithPepper(_pepper)).Do(ctx, _target)
+	require.NoError(t, err)
+
+	tcs := []struct {
+		key    string
+		sut    *hash.Hash
+		hashed string
+	}{
+		{
+			key:    "Other pepper",
+			sut:    hash.New(hash.WithMode(hash.ModeHmac), hash.WithPepper("other")),
+			hashed: hashed,
+		},
+		{
+			key:    "Hmac without pepper",
+			sut:    hash.New(),
+			hashed: hashed,
+		},
+		{
+			key:    "Malformed argon2id",
+			sut:    hash.New(),
+			hashed: "$argon2id$v=19$m=1024,t=1$c2FsdA$a2V5",
+		},
+		{
+			key:    "Argon2id above max",
+			sut:    hash.New(hash.WithArgon2Max(_argon2)),
+			hashed: "$argon2id$v=19$m=4194304,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U",
+		},
+		{
+			key:    "Argon2id zero time",
+			sut:    hash.New(),
+			hashed: "$argon2id$v=19$m=1024,t=0,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U",
+		},
+		{
+			key:    "Unknown scheme",
+			sut:    hash.New(),
+			hashed: "$md5$" + _target,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.key, func(t *testing.T) {
+			assert.ErrorIs(t, tc.sut.Check(ctx, tc.hashed, _target), models.ErrNotValidTokens)
+		})
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	ctx := context.Background()
+
+	hashes := map[string]string{}
+
+	for key, h := range map[string]*hash.Hash{
+		"bcrypt4":  hash.New(hash.WithBcryptCost(4)),
+		"bcrypt5":  hash.New(hash.WithBcryptCost(5)),
+		"sha256":   hash.New(hash.WithMode(hash.ModeSha256)),
+		"hmac":     hash.New(hash.WithMode(hash.ModeHmac), hash.WithPepper(_pepper)),
+		"argon2id": hash.New(hash.WithMode(hash.ModeArgon2id), hash.WithArgon2(_argon2)),
+	} {
+		hashed, err := h.Do(ctx, _target)
+		require.NoError(t, err)
+
+		hashes[key] = hashed
+	}
+
+	tcs := []struct {
+		key      string
+		sut      *hash.Hash
+		hashed   string
+		expected bool
+	}{
+		{
+			key:    "Same bcrypt cost",
+			sut:    hash.New(hash.WithBcryptCost(4)),
+			hashed: hashes["bcrypt4"],
+		},
+		{
+			key:      "Other bcrypt cost",
+			sut:      hash.New(hash.WithBcryptCost(4)),
+			hashed:   hashes["bcrypt5"],
+			expected: true,
+		},
+		{
+			key:    "Same argon2id params",
+			sut:    hash.New(hash.WithMode(hash.ModeArgon2id), hash.WithArgon2(_argon2)),
+			hashed: hashes["argon2id"],
+		},
+		{
+			key:      "Other argon2id memory",
+			sut:      hash.New(hash.WithMode(hash.ModeArgon2id), hash.WithArgon2(hash.Argon2{Memory: 2048, Time: 1, Parallelism: 1})),
+			hashed:   hashes["argon2id"],
+			expected: true,
+		},
+		{
+			key:      "Bcrypt to argon2id",
+			sut:      hash.New(hash.WithMode(hash.ModeArgon2id), hash.WithArgon2(_argon2)),
+			hashed:   hashes["bcrypt4"],
+			expected: true,
+		},
+		{
+			key:      "Sha256 to hmac",
+			sut:      hash.New(hash.WithMode(hash.ModeHmac), hash.WithPepper(_pepper)),
+			hashed:   hashes["sha256"],
+			expected: true,
+		},
+		{
+			key:    "Same hmac",
+			sut:    hash.New(hash.WithMode(hash.ModeHmac), hash.WithPepper(_pepper)),
+			hashed: hashes["hmac"],
+		},
+		{
+			key:      "Hmac to bcrypt",
+			sut:      hash.New(hash.WithBcryptCost(4)),
+			hashed:   hashes["hmac"],
+			expected: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.key, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.sut.NeedsRehash(tc.hashed))
+		})
+	}
+}
+
+func TestNewPanics(t *testing.T) {
+	assert.Panics(t, func() { hash.New(hash.WithMode(hash.ModeHmac)) })
+	assert.Panics(t, func() { hash.New(hash.WithMode("md5")) })
+	assert.Panics(t, func() { hash.New(hash.WithBcryptCost(50)) })
+	assert.Panics(t, func() {
+		hash.New(hash.WithMode(hash.ModeArgon2id), hash.WithArgon2(hash.Argon2{Memory: 1024, Time: 0, Parallelism: 1}))
+	})
+	assert.Panics(t, func() {
+		hash.New(hash.WithMode(hash.ModeArgon2id), hash.WithArgon2(_argon2), hash.WithArgon2Max(hash.Argon2{Memory: 512, Time: 1, Parallelism: 1}))
+	})
+}
+
+func benchmarkHash(b *testing.B, opts ...hash.Option) {
+	ctx := context.Background()
+	sut := hash.New(opts...)
+
+	hashed, err := sut.Do(ctx, _target)
+	require.NoError(b, err)
+
+	b.Run("Do", func(b *testing.B) {
+		for range b.N {
+			sut.Do(ctx, _target)
+		}
+	})
+
+	b.Run("Check", func(b *testing.B) {
+		for range b.N {
+			sut.Check(ctx, hashed, _target)
+		}
+	})
+}
+
+func BenchmarkBcrypt(b *testing.B) {
+	benchmarkHash(b)
+}
+
+func BenchmarkSha256(b *testing.B) {
+	benchmarkHash(b, hash.WithMode(hash.ModeSha256))
+}
+
+func BenchmarkHmac(b *testing.B) {
+	benchmarkHash(b, hash.WithMode(hash.ModeHmac), hash.WithPepper(_pepper))
+}
+
+func BenchmarkArgon2id(b *testing.B) {
+	benchmarkHash(b, hash.WithMode(hash.ModeArgon2id))
+}
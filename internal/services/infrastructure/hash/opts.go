@@ -0,0 +1,61 @@
+package hash
+
+import "time"
+
+const (
+	ModeBcrypt = "bcrypt"
+	ModeSha256 = "sha256"
+	ModeHmac   = "hmac"
+	// INFO: for low entropy secrets, too slow for tokens under load
+	ModeArgon2id = "argon2id"
+)
+
+// INFO: 256 MiB per check at most
+var DefaultArgon2Max = Argon2{Memory: 256 * 1024, Time: 16, Parallelism: 16}
+
+type Option func(*Hash)
+
+type Observer interface {
+	ObserveHash(op string, d time.Duration)
+}
+
+func WithObserver(o Observer) Option {
+	return func(h *Hash) {
+		h.observer = o
+	}
+}
+
+// INFO: scheme for new hashes, Check accepts any supported scheme
+// so stored hashes move to it on the next rotation
+func WithMode(mode string) Option {
+	return func(h *Hash) {
+		h.mode = mode
+	}
+}
+
+// INFO: server side secret for the hmac mode, kept out of the storage
+func WithPepper(pepper string) Option {
+	return func(h *Hash) {
+		h.pepper = []byte(pepper)
+	}
+}
+
+func WithBcryptCost(cost int) Option {
+	return func(h *Hash) {
+		h.bcryptCost = cost
+	}
+}
+
+func WithArgon2(p Argon2) Option {
+	return func(h *Hash) {
+		h.argon2 = p
+	}
+}
+
+// INFO: upper bound of both the configured params and those read
+// from stored hashes, stored hashes above it fail Check
+func WithArgon2Max(p Argon2) Option {
+	return func(h *Hash) {
+		h.argon2Max = p
+	}
+}
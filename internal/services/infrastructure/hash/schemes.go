@@ -0,0 +1,93 @@
+package hash
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/v1adhope/auth-service/internal/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// INFO: every stored hash starts with its scheme, legacy bcrypt hashes
+// of the raw target carry only their own $2a$/$2b$/$2y$ prefix
+const (
+	// INFO: followed by the bcrypt hash itself, $bcrypt-sha256$2a$10$...
+	_prefixBcrypt = "$bcrypt-sha256"
+	_prefixSha256 = "$sha256$"
+	_prefixHmac   = "$hmac-sha256$"
+
+	_bcryptMaxLen = 72
+)
+
+// INFO: bcrypt ignores input past 72 bytes, so the target is reduced
+// to its base64 sha256 digest (44 bytes) first
+func doBcrypt(cost int, target string) (string, error) {
+	hashBytes, err := bcrypt.GenerateFromPassword(prehash(target), cost)
+	if err != nil {
+		return "", fmt.Errorf("GenerateFromPassword: %w", models.ErrNotValidTokens)
+	}
+
+	return _prefixBcrypt + string(hashBytes), nil
+}
+
+func checkBcrypt(hashedTarget, target string) error {
+	return compareBcrypt(strings.TrimPrefix(hashedTarget, _prefixBcrypt), prehash(target))
+}
+
+// INFO: legacy hashes of the raw target, longer targets would only be compared
+// by their first 72 bytes and are refused
+func checkLegacyBcrypt(hashedTarget, target string) error {
+	if len(target) > _bcryptMaxLen {
+		return fmt.Errorf("checkLegacyBcrypt: target longer than %d bytes: %w", _bcryptMaxLen, models.ErrNotValidTokens)
+	}
+
+	return compareBcrypt(hashedTarget, []byte(target))
+}
+
+func prehash(target string) []byte {
+	sum := sha256.Sum256([]byte(target))
+
+	return []byte(base64.StdEncoding.EncodeToString(sum[:]))
+}
+
+func compareBcrypt(hashedTarget string, target []byte) error {
+	if err := bcrypt.CompareHashAndPassword([]byte(hashedTarget), target); err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return fmt.Errorf("CompareHashAndPassword: %w", models.ErrNotValidTokens)
+		}
+
+		return fmt.Errorf("CompareHashAndPassword: %w", err)
+	}
+
+	return nil
+}
+
+func doSha256(target string) string {
+	sum := sha256.Sum256([]byte(target))
+
+	return _prefixSha256 + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+func doHmac(pepper []byte, target string) string {
+	mac := hmac.New(sha256.New, pepper)
+	mac.Write([]byte(target))
+
+	return _prefixHmac + base64.RawStdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func checkEqual(hashedTarget, expected string) error {
+	if subtle.ConstantTimeCompare([]byte(hashedTarget), []byte(expected)) != 1 {
+		return fmt.Errorf("ConstantTimeCompare: %w", models.ErrNotValidTokens)
+	}
+
+	return nil
+}
+
+func isLegacyBcrypt(hashedTarget string) bool {
+	return strings.HasPrefix(hashedTarget, "$2")
+}
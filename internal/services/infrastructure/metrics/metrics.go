@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const _namespace = "auth_service"
+
+type Metrics struct {
+	registry        *prometheus.Registry
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	tokens          *prometheus.CounterVec
+	refreshFailures *prometheus.CounterVec
+	ipChanges       prometheus.Counter
+	hashDuration    *prometheus.HistogramVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route and status.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: _namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method and route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		tokens: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Name:      "tokens_total",
+			Help:      "Token pairs by event: issued, refreshed, revoked or replayed to a retry.",
+		}, []string{"event"}),
+		refreshFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Name:      "refresh_failures_total",
+			Help:      "Failed refreshes by reason.",
+		}, []string{"reason"}),
+		ipChanges: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Name:      "ip_change_alerts_total",
+			Help:      "Alerts sent because a refresh came from another ip.",
+		}),
+		hashDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: _namespace,
+			Name:      "hash_duration_seconds",
+			Help:      "Refresh token hashing latency by operation.",
+			Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"op"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.tokens,
+		m.refreshFailures,
+		m.ipChanges,
+		m.hashDuration,
+	)
+
+	return m
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+func (m *Metrics) RegisterPool(pool *pgxpool.Pool) {
+	m.registry.MustRegister(newPoolCollector(pool))
+}
+
+func (m *Metrics) ObserveRequest(method, route string, status int, d time.Duration) {
+	m.requests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.requestDuration.WithLabelValues(method, route).Observe(d.Seconds())
+}
+
+func (m *Metrics) ObserveHash(op string, d time.Duration) {
+	m.hashDuration.WithLabelValues(op).Observe(d.Seconds())
+}
+
+func (m *Metrics) TokenIssued() {
+	m.tokens.WithLabelValues("issued").Inc()
+}
+
+func (m *Metrics) TokenRefreshed() {
+	m.tokens.WithLabelValues("refreshed").Inc()
+}
+
+func (m *Metrics) TokenRevoked() {
+	m.tokens.WithLabelValues("revoked").Inc()
+}
+
+func (m *Metrics) TokenReplayed() {
+	m.tokens.WithLabelValues("replayed").Inc()
+}
+
+func (m *Metrics) RefreshFailed(reason string) {
+	m.refreshFailures.WithLabelValues(reason).Inc()
+}
+
+func (m *Metrics) IpChanged() {
+	m.ipChanges.Inc()
+}
@@ -0,0 +1,77 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/metrics"
+)
+
+func TestMetrics(t *testing.T) {
+	m := metrics.New()
+
+	m.TokenIssued()
+	m.TokenIssued()
+	m.TokenRefreshed()
+	m.TokenRevoked()
+	m.TokenReplayed()
+	m.RefreshFailed("hash_mismatch")
+	m.IpChanged()
+	m.ObserveHash("do", 50*time.Millisecond)
+	m.ObserveRequest("POST", "/v1/tokens/refresh", http.StatusBadRequest, time.Millisecond)
+
+	tcs := []struct {
+		key      string
+		expected string
+	}{
+		{
+			key:      "Issued",
+			expected: `auth_service_tokens_total{event="issued"} 2`,
+		},
+		{
+			key:      "Refreshed",
+			expected: `auth_service_tokens_total{event="refreshed"} 1`,
+		},
+		{
+			key:      "Revoked",
+			expected: `auth_service_tokens_total{event="revoked"} 1`,
+		},
+		{
+			key:      "Replayed",
+			expected: `auth_service_tokens_total{event="replayed"} 1`,
+		},
+		{
+			key:      "Refresh failures",
+			expected: `auth_service_refresh_failures_total{reason="hash_mismatch"} 1`,
+		},
+		{
+			key:      "Ip changes",
+			expected: `auth_service_ip_change_alerts_total 1`,
+		},
+		{
+			key:      "Hash latency",
+			expected: `auth_service_hash_duration_seconds_count{op="do"} 1`,
+		},
+		{
+			key:      "Requests",
+			expected: `auth_service_http_requests_total{method="POST",route="/v1/tokens/refresh",status="400"} 1`,
+		},
+	}
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	sut := w.Body.String()
+
+	for _, tc := range tcs {
+		t.Run("", func(t *testing.T) {
+			assert.True(t, strings.Contains(sut, tc.expected), tc.key)
+		})
+	}
+}
@@ -9,15 +9,14 @@ import (
 	"github.com/Masterminds/squirrel"
 	"github.com/jackc/pgx/v5"
 	"github.com/v1adhope/auth-service/internal/models"
+	"github.com/v1adhope/auth-service/pkg/tracing"
 )
 
-func (r *Repos) StoreToken(ctx context.Context, id, token string, now time.Time) error {
-	sql, args, err := r.Builder.Insert("auth_whitelist").
-		SetMap(squirrel.Eq{
-			"id":         id,
-			"created_at": now,
-			"token":      token,
-		}).ToSql()
+func (r *Repos) StoreToken(ctx context.Context, id, userId, token string, now time.Time) (err error) {
+	ctx, span := startSpan(ctx, "repositories.StoreToken", "INSERT", "auth_whitelist")
+	defer func() { tracing.End(span, err) }()
+
+	sql, args, err := r.insertToken(id, userId, token, now).ToSql()
 	if err != nil {
 		return fmt.Errorf("repositories: auth: StoreToken: ToSql: %w", err)
 	}
@@ -29,11 +28,131 @@ func (r *Repos) StoreToken(ctx context.Context, id, token string, now time.Time)
 	return nil
 }
 
-func (r *Repos) GetToken(ctx context.Context, id string) (string, error) {
+// INFO: on a conflicting key the insert waits for the concurrent transaction
+// holding it, so the record read afterwards is always committed. An expired
+// record under the key is overwritten in place, the rest are left to gc
+func (r *Repos) StoreTokenIdempotent(ctx context.Context, id, userId, token string, now time.Time, idem models.Idempotency) (_ models.Idempotency, stored bool, err error) {
+	ctx, span := startSpan(ctx, "repositories.StoreTokenIdempotent", "INSERT", "auth_idempotency")
+	defer func() { tracing.End(span, err) }()
+
+	idemSql, idemArgs, err := r.Builder.Insert("auth_idempotency").
+		SetMap(squirrel.Eq{
+			"key":          idem.Key,
+			"request_hash": idem.RequestHash,
+			"session_id":   idem.SessionId,
+			"response":     idem.Response,
+			"expires_at":   idem.ExpiresAt,
+		}).
+		Suffix(`ON CONFLICT (key) DO UPDATE SET
+			request_hash = excluded.request_hash,
+			session_id = excluded.session_id,
+			response = excluded.response,
+			expires_at = excluded.expires_at
+			WHERE auth_idempotency.expires_at <= ?`, now).
+		ToSql()
+	if err != nil {
+		return models.Idempotency{}, false, fmt.Errorf("repositories: auth: StoreTokenIdempotent: idempotency: ToSql: %w", err)
+	}
+
+	existingSql, existingArgs, err := r.Builder.Select("key", "request_hash", "session_id", "response", "expires_at").
+		From("auth_idempotency").
+		Where(squirrel.Eq{
+			"key": idem.Key,
+		}).
+		ToSql()
+	if err != nil {
+		return models.Idempotency{}, false, fmt.Errorf("repositories: auth: StoreTokenIdempotent: existing: ToSql: %w", err)
+	}
+
+	insertSql, insertArgs, err := r.insertToken(id, userId, token, now).ToSql()
+	if err != nil {
+		return models.Idempotency{}, false, fmt.Errorf("repositories: auth: StoreTokenIdempotent: insert: ToSql: %w", err)
+	}
+
+	existing := models.Idempotency{}
+
+	err = r.InTx(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, idemSql, idemArgs...)
+		if err != nil {
+			return fmt.Errorf("repositories: auth: StoreTokenIdempotent: idempotency: Exec: %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			if err := tx.QueryRow(ctx, existingSql, existingArgs...).Scan(
+				&existing.Key,
+				&existing.RequestHash,
+				&existing.SessionId,
+				&existing.Response,
+				&existing.ExpiresAt,
+			); err != nil {
+				return fmt.Errorf("repositories: auth: StoreTokenIdempotent: existing: Scan: %w", err)
+			}
+
+			return nil
+		}
+
+		if _, err := tx.Exec(ctx, insertSql, insertArgs...); err != nil {
+			return fmt.Errorf("repositories: auth: StoreTokenIdempotent: insert: Exec: %w", err)
+		}
+
+		stored = true
+
+		return nil
+	})
+	if err != nil {
+		return models.Idempotency{}, false, err
+	}
+
+	if !stored {
+		return existing, false, nil
+	}
+
+	return idem, true, nil
+}
+
+func (r *Repos) GetIdempotency(ctx context.Context, key string) (_ models.Idempotency, err error) {
+	ctx, span := startSpan(ctx, "repositories.GetIdempotency", "SELECT", "auth_idempotency")
+	defer func() { tracing.End(span, err) }()
+
+	sql, args, err := r.Builder.Select("key", "request_hash", "session_id", "response", "expires_at").
+		From("auth_idempotency").
+		Where(squirrel.And{
+			squirrel.Eq{"key": key},
+			squirrel.Gt{"expires_at": time.Now()},
+		}).
+		ToSql()
+	if err != nil {
+		return models.Idempotency{}, fmt.Errorf("repositories: auth: GetIdempotency: ToSql: %w", err)
+	}
+
+	idem := models.Idempotency{}
+
+	if err := r.Pool.QueryRow(ctx, sql, args...).Scan(
+		&idem.Key,
+		&idem.RequestHash,
+		&idem.SessionId,
+		&idem.Response,
+		&idem.ExpiresAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Idempotency{}, fmt.Errorf("repositories: auth: GetIdempotency: Scan: %w", models.ErrNotValidTokens)
+		}
+
+		return models.Idempotency{}, fmt.Errorf("repositories: auth: GetIdempotency: Scan: %w", err)
+	}
+
+	return idem, nil
+}
+
+func (r *Repos) GetToken(ctx context.Context, id string) (_ string, err error) {
+	ctx, span := startSpan(ctx, "repositories.GetToken", "SELECT", "auth_whitelist")
+	defer func() { tracing.End(span, err) }()
+
 	sql, args, err := r.Builder.Select("token").
 		From("auth_whitelist").
-		Where(squirrel.Eq{
-			"id": id,
+		Where(squirrel.And{
+			squirrel.Eq{"id": id},
+			r.notExpired(time.Now()),
 		}).
 		ToSql()
 	if err != nil {
@@ -53,7 +172,12 @@ func (r *Repos) GetToken(ctx context.Context, id string) (string, error) {
 	return token, nil
 }
 
-func (r *Repos) DestroyToken(ctx context.Context, id string) error {
+// INFO: the grace leading to the token goes with it,
+// otherwise a replayed predecessor would hand it out again
+func (r *Repos) DestroyToken(ctx context.Context, id string) (err error) {
+	ctx, span := startSpan(ctx, "repositories.DestroyToken", "DELETE", "auth_whitelist")
+	defer func() { tracing.End(span, err) }()
+
 	sql, args, err := r.Builder.Delete("auth_whitelist").
 		Where(squirrel.Eq{
 			"id": id,
@@ -63,9 +187,138 @@ func (r *Repos) DestroyToken(ctx context.Context, id string) error {
 		return fmt.Errorf("repositories: auth: DestroyToken: ToSql: %w", err)
 	}
 
-	if _, err := r.Pool.Exec(ctx, sql, args...); err != nil {
-		return fmt.Errorf("repositories: auth: DestroyToken: Exec: %w", err)
+	graceSql, graceArgs, err := r.Builder.Delete("auth_grace").
+		Where(squirrel.Eq{
+			"successor_id": id,
+		}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("repositories: auth: DestroyToken: grace: ToSql: %w", err)
 	}
 
-	return nil
+	return r.InTx(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, sql, args...); err != nil {
+			return fmt.Errorf("repositories: auth: DestroyToken: Exec: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, graceSql, graceArgs...); err != nil {
+			return fmt.Errorf("repositories: auth: DestroyToken: grace: Exec: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// INFO: the delete takes the row lock, so of concurrent rotations
+// of the same token exactly one sees the row and the rest get ErrNotValidTokens
+func (r *Repos) RotateToken(ctx context.Context, id, token, newId, userId, newToken string, now time.Time, grace models.Grace) (err error) {
+	ctx, span := startSpan(ctx, "repositories.RotateToken", "DELETE", "auth_whitelist")
+	defer func() { tracing.End(span, err) }()
+
+	deleteSql, deleteArgs, err := r.Builder.Delete("auth_whitelist").
+		Where(squirrel.And{
+			squirrel.Eq{"id": id, "token": token},
+			r.notExpired(now),
+		}).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("repositories: auth: RotateToken: delete: ToSql: %w", err)
+	}
+
+	insertSql, insertArgs, err := r.insertToken(newId, userId, newToken, now).ToSql()
+	if err != nil {
+		return fmt.Errorf("repositories: auth: RotateToken: insert: ToSql: %w", err)
+	}
+
+	// INFO: the grace leading to the rotated token would hand it out again
+	staleSql, staleArgs, err := r.Builder.Delete("auth_grace").
+		Where(squirrel.Eq{
+			"successor_id": id,
+		}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("repositories: auth: RotateToken: stale: ToSql: %w", err)
+	}
+
+	graceSql, graceArgs, err := r.Builder.Insert("auth_grace").
+		SetMap(squirrel.Eq{
+			"id":           id,
+			"token":        grace.Token,
+			"successor":    grace.Successor,
+			"successor_id": newId,
+			"expires_at":   grace.ExpiresAt,
+		}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("repositories: auth: RotateToken: grace: ToSql: %w", err)
+	}
+
+	return r.InTx(ctx, func(tx pgx.Tx) error {
+		deleted := ""
+
+		if err := tx.QueryRow(ctx, deleteSql, deleteArgs...).Scan(&deleted); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("repositories: auth: RotateToken: Scan: %w", models.ErrNotValidTokens)
+			}
+
+			return fmt.Errorf("repositories: auth: RotateToken: Scan: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, insertSql, insertArgs...); err != nil {
+			return fmt.Errorf("repositories: auth: RotateToken: Exec: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, staleSql, staleArgs...); err != nil {
+			return fmt.Errorf("repositories: auth: RotateToken: stale: Exec: %w", err)
+		}
+
+		if grace.ExpiresAt.IsZero() {
+			return nil
+		}
+
+		if _, err := tx.Exec(ctx, graceSql, graceArgs...); err != nil {
+			return fmt.Errorf("repositories: auth: RotateToken: grace: Exec: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (r *Repos) GetGrace(ctx context.Context, id string) (_ models.Grace, err error) {
+	ctx, span := startSpan(ctx, "repositories.GetGrace", "SELECT", "auth_grace")
+	defer func() { tracing.End(span, err) }()
+
+	sql, args, err := r.Builder.Select("token", "successor", "expires_at").
+		From("auth_grace").
+		Where(squirrel.And{
+			squirrel.Eq{"id": id},
+			squirrel.Gt{"expires_at": time.Now()},
+		}).
+		ToSql()
+	if err != nil {
+		return models.Grace{}, fmt.Errorf("repositories: auth: GetGrace: ToSql: %w", err)
+	}
+
+	grace := models.Grace{}
+
+	if err := r.Pool.QueryRow(ctx, sql, args...).Scan(&grace.Token, &grace.Successor, &grace.ExpiresAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Grace{}, fmt.Errorf("repositories: auth: GetGrace: Scan: %w", models.ErrNotValidTokens)
+		}
+
+		return models.Grace{}, fmt.Errorf("repositories: auth: GetGrace: Scan: %w", err)
+	}
+
+	return grace, nil
+}
+
+func (r *Repos) insertToken(id, userId, token string, now time.Time) squirrel.InsertBuilder {
+	return r.Builder.Insert("auth_whitelist").
+		SetMap(squirrel.Eq{
+			"id":         id,
+			"user_id":    userId,
+			"created_at": now,
+			"token":      token,
+		})
 }
@@ -0,0 +1,38 @@
+package repositories_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/repositories"
+	"github.com/v1adhope/auth-service/internal/testhelpers"
+	"github.com/v1adhope/auth-service/pkg/postgresql"
+)
+
+func TestConformance(t *testing.T) {
+	ctx := context.Background()
+
+	pgC, err := testhelpers.BuildContainer(ctx)
+	if err != nil {
+		t.Skipf("postgres container: %v", err)
+	}
+	t.Cleanup(func() { pgC.Terminate(ctx) })
+
+	require.NoError(t, pgC.MigrateUp())
+
+	postgres, err := postgresql.Build(ctx, postgresql.WithConnStr(pgC.ConnStr))
+	require.NoError(t, err)
+	t.Cleanup(postgres.Close)
+
+	testhelpers.WhitelistSuite{
+		New: func(t *testing.T) testhelpers.Whitelist {
+			_, err := postgres.Pool.Exec(ctx, "truncate auth_whitelist, auth_grace, auth_idempotency")
+			require.NoError(t, err)
+
+			return repositories.New(postgres, repositories.WithTtl(time.Hour))
+		},
+		Ttl: time.Hour,
+	}.Run(t)
+}
@@ -1,11 +1,31 @@
 package repositories
 
-import "github.com/v1adhope/auth-service/pkg/postgresql"
+import (
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/v1adhope/auth-service/pkg/postgresql"
+)
 
 type Repos struct {
 	*postgresql.Postgres
+	ttl time.Duration
 }
 
-func New(driver *postgresql.Postgres) *Repos {
-	return &Repos{driver}
+func New(driver *postgresql.Postgres, opts ...Option) *Repos {
+	cfg := config(opts...)
+
+	return &Repos{
+		Postgres: driver,
+		ttl:      cfg.Ttl,
+	}
+}
+
+// INFO: expired rows stay until gc but are invisible to reads
+func (r *Repos) notExpired(now time.Time) squirrel.Sqlizer {
+	if r.ttl <= 0 {
+		return squirrel.And{}
+	}
+
+	return squirrel.Gt{"created_at": now.Add(-r.ttl)}
 }
@@ -0,0 +1,282 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/v1adhope/auth-service/internal/models"
+)
+
+type entry struct {
+	userId    string
+	token     string
+	createdAt time.Time
+	expiresAt time.Time
+	// INFO: id of the rotated token whose grace leads here, if any
+	graceOf string
+}
+
+func (e entry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// INFO: process local whitelist, sessions don't survive a restart
+// and aren't shared between replicas
+type Repos struct {
+	mu      sync.RWMutex
+	entries map[string]entry
+	graces  map[string]models.Grace
+	idems   map[string]models.Idempotency
+	ttl     time.Duration
+}
+
+func New(opts ...Option) *Repos {
+	cfg := config(opts...)
+
+	return &Repos{
+		entries: make(map[string]entry),
+		graces:  make(map[string]models.Grace),
+		idems:   make(map[string]models.Idempotency),
+		ttl:     cfg.Ttl,
+	}
+}
+
+func (r *Repos) StoreToken(ctx context.Context, id, userId, token string, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.store(id, userId, token, now); err != nil {
+		return fmt.Errorf("repositories: memory: StoreToken: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repos) StoreTokenIdempotent(ctx context.Context, id, userId, token string, now time.Time, idem models.Idempotency) (models.Idempotency, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.idems[idem.Key]; ok && now.Before(existing.ExpiresAt) {
+		return existing, false, nil
+	}
+
+	if err := r.store(id, userId, token, now); err != nil {
+		return models.Idempotency{}, false, fmt.Errorf("repositories: memory: StoreTokenIdempotent: %w", err)
+	}
+
+	r.idems[idem.Key] = idem
+
+	return idem, true, nil
+}
+
+func (r *Repos) GetToken(ctx context.Context, id string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	e, ok := r.entries[id]
+	if !ok || e.expired(time.Now()) {
+		return "", fmt.Errorf("repositories: memory: GetToken: %w", models.ErrNotValidTokens)
+	}
+
+	return e.token, nil
+}
+
+func (r *Repos) DestroyToken(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.remove(id)
+
+	return nil
+}
+
+func (r *Repos) RotateToken(ctx context.Context, id, token, newId, userId, newToken string, now time.Time, grace models.Grace) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.entries[id]
+	if !ok || e.expired(time.Now()) || e.token != token {
+		return fmt.Errorf("repositories: memory: RotateToken: %w", models.ErrNotValidTokens)
+	}
+
+	if err := r.store(newId, userId, newToken, now); err != nil {
+		return fmt.Errorf("repositories: memory: RotateToken: %w", err)
+	}
+
+	r.remove(id)
+
+	if !grace.ExpiresAt.IsZero() {
+		r.graces[id] = grace
+
+		successor := r.entries[newId]
+		successor.graceOf = id
+		r.entries[newId] = successor
+	}
+
+	return nil
+}
+
+func (r *Repos) GetIdempotency(ctx context.Context, key string) (models.Idempotency, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	idem, ok := r.idems[key]
+	if !ok || !time.Now().Before(idem.ExpiresAt) {
+		return models.Idempotency{}, fmt.Errorf("repositories: memory: GetIdempotency: %w", models.ErrNotValidTokens)
+	}
+
+	return idem, nil
+}
+
+func (r *Repos) GetGrace(ctx context.Context, id string) (models.Grace, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	grace, ok := r.graces[id]
+	if !ok || !time.Now().Before(grace.ExpiresAt) {
+		return models.Grace{}, fmt.Errorf("repositories: memory: GetGrace: %w", models.ErrNotValidTokens)
+	}
+
+	return grace, nil
+}
+
+func (r *Repos) ListSessions(ctx context.Context, userId string) ([]models.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	sessions := make([]models.Session, 0)
+
+	for id, e := range r.entries {
+		if e.userId != userId || e.expired(now) {
+			continue
+		}
+
+		sessions = append(sessions, models.Session{
+			Id:        id,
+			UserId:    e.userId,
+			CreatedAt: e.createdAt,
+		})
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+
+	return sessions, nil
+}
+
+func (r *Repos) DestroyUserTokens(ctx context.Context, userId string) (int64, error) {
+	return r.destroy(func(e entry) bool {
+		return e.userId == userId
+	}), nil
+}
+
+func (r *Repos) DestroyTokensBefore(ctx context.Context, before time.Time) (int64, error) {
+	return r.destroy(func(e entry) bool {
+		return !e.createdAt.After(before)
+	}), nil
+}
+
+// INFO: drops expired tokens, GetToken already ignores them
+// so this only bounds memory usage
+func (r *Repos) Evict(now time.Time) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := int64(0)
+
+	for id, e := range r.entries {
+		if e.expired(now) {
+			delete(r.entries, id)
+			n++
+		}
+	}
+
+	for id, grace := range r.graces {
+		if !now.Before(grace.ExpiresAt) {
+			delete(r.graces, id)
+		}
+	}
+
+	for key, idem := range r.idems {
+		if !now.Before(idem.ExpiresAt) {
+			delete(r.idems, key)
+		}
+	}
+
+	return n
+}
+
+// INFO: evicts expired tokens every interval until ctx is done
+func (r *Repos) RunEviction(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			r.Evict(now)
+		}
+	}
+}
+
+// INFO: expired tokens are dropped as well but not counted,
+// matching backends that expire them on their own
+func (r *Repos) destroy(match func(e entry) bool) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	n := int64(0)
+
+	for id, e := range r.entries {
+		if !match(e) {
+			continue
+		}
+
+		if !e.expired(now) {
+			n++
+		}
+
+		r.remove(id)
+	}
+
+	return n
+}
+
+// INFO: caller holds the write lock, the grace leading to the token goes
+// with it, whether destroyed or rotated, so a replay can't hand it out again
+func (r *Repos) remove(id string) {
+	if e, ok := r.entries[id]; ok && e.graceOf != "" {
+		delete(r.graces, e.graceOf)
+	}
+
+	delete(r.entries, id)
+}
+
+// INFO: caller holds the write lock
+func (r *Repos) store(id, userId, token string, now time.Time) error {
+	if e, ok := r.entries[id]; ok && !e.expired(time.Now()) {
+		return fmt.Errorf("id %s already stored", id)
+	}
+
+	e := entry{
+		userId:    userId,
+		token:     token,
+		createdAt: now,
+	}
+
+	if r.ttl > 0 {
+		e.expiresAt = now.Add(r.ttl)
+	}
+
+	r.entries[id] = e
+
+	return nil
+}
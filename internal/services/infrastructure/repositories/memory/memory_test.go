@@ -0,0 +1,78 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/repositories/memory"
+	"github.com/v1adhope/auth-service/internal/testhelpers"
+)
+
+func TestConformance(t *testing.T) {
+	testhelpers.WhitelistSuite{
+		New: func(t *testing.T) testhelpers.Whitelist {
+			return memory.New(memory.WithTtl(time.Hour))
+		},
+		Ttl: time.Hour,
+	}.Run(t)
+}
+
+func TestConformanceWithoutTtl(t *testing.T) {
+	testhelpers.WhitelistSuite{
+		New: func(t *testing.T) testhelpers.Whitelist {
+			return memory.New()
+		},
+	}.Run(t)
+}
+
+func TestEvict(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	sut := memory.New(memory.WithTtl(time.Minute))
+
+	assert.NoError(t, sut.StoreToken(ctx, uuid.NewString(), uuid.NewString(), "hashed", now.Add(-2*time.Minute)))
+	assert.NoError(t, sut.StoreToken(ctx, uuid.NewString(), uuid.NewString(), "hashed", now))
+
+	assert.Equal(t, int64(1), sut.Evict(now))
+	assert.Equal(t, int64(0), sut.Evict(now))
+	assert.Equal(t, int64(1), sut.Evict(now.Add(time.Minute)))
+}
+
+func TestConcurrentUse(t *testing.T) {
+	ctx := context.Background()
+	sut := memory.New(memory.WithTtl(time.Minute))
+	userId := uuid.NewString()
+	done := make(chan struct{})
+
+	for range 8 {
+		go func() {
+			defer func() { done <- struct{}{} }()
+
+			for range 100 {
+				id := uuid.NewString()
+
+				assert.NoError(t, sut.StoreToken(ctx, id, userId, "hashed", time.Now()))
+
+				_, err := sut.GetToken(ctx, id)
+				assert.NoError(t, err)
+
+				_, err = sut.ListSessions(ctx, userId)
+				assert.NoError(t, err)
+
+				assert.NoError(t, sut.DestroyToken(ctx, id))
+			}
+		}()
+	}
+
+	for range 8 {
+		<-done
+	}
+
+	sessions, err := sut.ListSessions(ctx, userId)
+
+	assert.NoError(t, err)
+	assert.Empty(t, sessions)
+}
@@ -0,0 +1,26 @@
+package repositories
+
+import "time"
+
+type Option func(*Config)
+
+type Config struct {
+	Ttl time.Duration
+}
+
+// INFO: zero keeps tokens until they are destroyed or collected by gc
+func WithTtl(ttl time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.Ttl = ttl
+	}
+}
+
+func config(opts ...Option) Config {
+	cfg := Config{}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return cfg
+}
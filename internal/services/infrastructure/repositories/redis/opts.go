@@ -0,0 +1,35 @@
+package redis
+
+import "time"
+
+type Option func(*Config)
+
+type Config struct {
+	Ttl    time.Duration
+	Prefix string
+}
+
+// INFO: zero keeps tokens until they are destroyed
+func WithTtl(ttl time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.Ttl = ttl
+	}
+}
+
+func WithPrefix(prefix string) Option {
+	return func(cfg *Config) {
+		cfg.Prefix = prefix
+	}
+}
+
+func config(opts ...Option) Config {
+	cfg := Config{
+		Prefix: "auth_whitelist",
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return cfg
+}
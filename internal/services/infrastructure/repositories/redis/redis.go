@@ -0,0 +1,482 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/v1adhope/auth-service/internal/models"
+	"github.com/v1adhope/auth-service/pkg/redis"
+	"github.com/v1adhope/auth-service/pkg/tracing"
+)
+
+type Repos struct {
+	*redis.Redis
+	ttl    time.Duration
+	prefix string
+}
+
+func New(driver *redis.Redis, opts ...Option) *Repos {
+	cfg := config(opts...)
+
+	return &Repos{
+		Redis:  driver,
+		ttl:    cfg.Ttl,
+		prefix: hashTag(cfg.Prefix),
+	}
+}
+
+// INFO: redis cluster hashes only the part in braces, so all keys of the prefix share a slot
+func hashTag(prefix string) string {
+	if strings.Contains(prefix, "{") {
+		return prefix
+	}
+
+	return "{" + prefix + "}"
+}
+
+func (r *Repos) tokenKey(id string) string {
+	return r.prefix + ":token:" + id
+}
+
+func (r *Repos) userKey(userId string) string {
+	return r.prefix + ":user:" + userId
+}
+
+func (r *Repos) graceKey(id string) string {
+	return r.prefix + ":grace:" + id
+}
+
+func (r *Repos) idempotencyKey(key string) string {
+	return r.prefix + ":idempotency:" + key
+}
+
+func (r *Repos) createdKey() string {
+	return r.prefix + ":created"
+}
+
+// INFO: unix milliseconds for pexpireat, zero disables expiry
+func (r *Repos) expireAt(now time.Time) int64 {
+	if r.ttl <= 0 {
+		return 0
+	}
+
+	return now.Add(r.ttl).UnixMilli()
+}
+
+// INFO: unix microseconds, created set entries scored below it belong to expired tokens
+func (r *Repos) expiredBefore(now time.Time) int64 {
+	if r.ttl <= 0 {
+		return 0
+	}
+
+	return now.Add(-r.ttl).UnixMicro()
+}
+
+const (
+	// INFO: a script reply when a token changed between reading its fields and running it
+	_changed = -2
+
+	_maxAttempts = 3
+	_batchSize   = 256
+)
+
+var errChanged = errors.New("token kept changing while destroying it")
+
+// INFO: fields of a stored token naming other keys, empty if it isn't stored
+type tokenRef struct {
+	id     string
+	userId string
+	grace  string
+}
+
+func (r *Repos) readRefs(ctx context.Context, ids []string) ([]tokenRef, error) {
+	cmds := make([]*goredis.SliceCmd, len(ids))
+
+	if _, err := r.Client.Pipelined(ctx, func(p goredis.Pipeliner) error {
+		for i, id := range ids {
+			cmds[i] = p.HMGet(ctx, r.tokenKey(id), "user_id", "grace")
+		}
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("readRefs: Pipelined: %w", err)
+	}
+
+	refs := make([]tokenRef, len(ids))
+
+	for i, cmd := range cmds {
+		fields := cmd.Val()
+
+		refs[i].id = ids[i]
+		refs[i].userId, _ = fields[0].(string)
+		refs[i].grace, _ = fields[1].(string)
+	}
+
+	return refs, nil
+}
+
+// INFO: userId, if known, stands in for the owner of tokens already expired
+// so they are dropped from its set as well
+func (r *Repos) destroy(ctx context.Context, ids []string, userId string) (int64, error) {
+	for range _maxAttempts {
+		refs, err := r.readRefs(ctx, ids)
+		if err != nil {
+			return 0, fmt.Errorf("destroy: %w", err)
+		}
+
+		keys := make([]string, 0, 1+3*len(refs))
+		args := make([]any, 0, 3*len(refs))
+
+		keys = append(keys, r.createdKey())
+
+		for _, ref := range refs {
+			if ref.userId == "" {
+				ref.userId = userId
+			}
+
+			token := r.tokenKey(ref.id)
+			owner, grace := token, token
+
+			if ref.userId != "" {
+				owner = r.userKey(ref.userId)
+			}
+
+			if ref.grace != "" {
+				grace = r.graceKey(ref.grace)
+			}
+
+			keys = append(keys, token, owner, grace)
+			args = append(args, ref.id, ref.userId, ref.grace)
+		}
+
+		n, err := _destroyScript.Run(ctx, r.Client, keys, args...).Int64()
+		if err != nil {
+			return 0, fmt.Errorf("destroy: Run: %w", err)
+		}
+
+		if n != _changed {
+			return n, nil
+		}
+	}
+
+	return 0, fmt.Errorf("destroy: %w", errChanged)
+}
+
+// INFO: destroys ids in batches, so a large user or gc run doesn't block redis in one script
+func (r *Repos) destroyAll(ctx context.Context, ids []string, userId string) (int64, error) {
+	total := int64(0)
+
+	for start := 0; start < len(ids); start += _batchSize {
+		n, err := r.destroy(ctx, ids[start:min(start+_batchSize, len(ids))], userId)
+		if err != nil {
+			return total, err
+		}
+
+		total += n
+	}
+
+	return total, nil
+}
+
+func (r *Repos) StoreToken(ctx context.Context, id, userId, token string, now time.Time) (err error) {
+	ctx, span := startSpan(ctx, "repositories.StoreToken", "EVALSHA")
+	defer func() { tracing.End(span, err) }()
+
+	stored, err := _storeScript.Run(
+		ctx,
+		r.Client,
+		[]string{r.tokenKey(id), r.userKey(userId), r.createdKey()},
+		id, userId, token, now.UnixMicro(), r.expireAt(now), r.expiredBefore(now),
+	).Int()
+	if err != nil {
+		return fmt.Errorf("repositories: redis: StoreToken: Run: %w", err)
+	}
+
+	if stored == 0 {
+		return fmt.Errorf("repositories: redis: StoreToken: id %s already stored", id)
+	}
+
+	return nil
+}
+
+func (r *Repos) StoreTokenIdempotent(ctx context.Context, id, userId, token string, now time.Time, idem models.Idempotency) (_ models.Idempotency, stored bool, err error) {
+	ctx, span := startSpan(ctx, "repositories.StoreTokenIdempotent", "EVALSHA")
+	defer func() { tracing.End(span, err) }()
+
+	res, err := _storeIdempotentScript.Run(
+		ctx,
+		r.Client,
+		[]string{r.tokenKey(id), r.userKey(userId), r.createdKey(), r.idempotencyKey(idem.Key)},
+		id, userId, token, now.UnixMicro(), r.expireAt(now),
+		idem.RequestHash, idem.SessionId, idem.Response, idem.ExpiresAt.UnixMilli(), r.expiredBefore(now),
+	).Result()
+	if err != nil {
+		return models.Idempotency{}, false, fmt.Errorf("repositories: redis: StoreTokenIdempotent: Run: %w", err)
+	}
+
+	existing, ok := res.([]any)
+	if !ok {
+		return models.Idempotency{}, false, fmt.Errorf("repositories: redis: StoreTokenIdempotent: id %s already stored", id)
+	}
+
+	if len(existing) == 0 {
+		return idem, true, nil
+	}
+
+	if len(existing) != 4 {
+		return models.Idempotency{}, false, fmt.Errorf("repositories: redis: StoreTokenIdempotent: unexpected reply %v", existing)
+	}
+
+	requestHash, _ := existing[0].(string)
+	sessionId, _ := existing[1].(string)
+	response, _ := existing[2].(string)
+	ttl, _ := existing[3].(int64)
+
+	return models.Idempotency{
+		Key:         idem.Key,
+		RequestHash: requestHash,
+		SessionId:   sessionId,
+		Response:    response,
+		ExpiresAt:   time.Now().Add(time.Duration(ttl) * time.Millisecond),
+	}, false, nil
+}
+
+func (r *Repos) GetToken(ctx context.Context, id string) (_ string, err error) {
+	ctx, span := startSpan(ctx, "repositories.GetToken", "HGET")
+	defer func() { tracing.End(span, err) }()
+
+	token, err := r.Client.HGet(ctx, r.tokenKey(id), "token").Result()
+	if err != nil {
+		if errors.Is(err, goredis.Nil) {
+			return "", fmt.Errorf("repositories: redis: GetToken: HGet: %w", models.ErrNotValidTokens)
+		}
+
+		return "", fmt.Errorf("repositories: redis: GetToken: HGet: %w", err)
+	}
+
+	return token, nil
+}
+
+func (r *Repos) DestroyToken(ctx context.Context, id string) (err error) {
+	ctx, span := startSpan(ctx, "repositories.DestroyToken", "EVALSHA")
+	defer func() { tracing.End(span, err) }()
+
+	if _, err := r.destroy(ctx, []string{id}, ""); err != nil {
+		return fmt.Errorf("repositories: redis: DestroyToken: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repos) RotateToken(ctx context.Context, id, token, newId, userId, newToken string, now time.Time, grace models.Grace) (err error) {
+	ctx, span := startSpan(ctx, "repositories.RotateToken", "EVALSHA")
+	defer func() { tracing.End(span, err) }()
+
+	graceExpireAt := int64(0)
+	if !grace.ExpiresAt.IsZero() {
+		graceExpireAt = grace.ExpiresAt.UnixMilli()
+	}
+
+	rotated := _changed
+
+	for attempt := 0; attempt < _maxAttempts && rotated == _changed; attempt++ {
+		refs, err := r.readRefs(ctx, []string{id})
+		if err != nil {
+			return fmt.Errorf("repositories: redis: RotateToken: %w", err)
+		}
+
+		owner, stale := r.tokenKey(id), r.tokenKey(id)
+
+		if refs[0].userId != "" {
+			owner = r.userKey(refs[0].userId)
+		}
+
+		if refs[0].grace != "" {
+			stale = r.graceKey(refs[0].grace)
+		}
+
+		rotated, err = _rotateScript.Run(
+			ctx,
+			r.Client,
+			[]string{r.tokenKey(id), r.tokenKey(newId), r.userKey(userId), r.createdKey(), r.graceKey(id), owner, stale},
+			token, id, newId, userId, newToken, now.UnixMicro(), r.expireAt(now),
+			grace.Token, grace.Successor, graceExpireAt, r.expiredBefore(now), refs[0].userId, refs[0].grace,
+		).Int()
+		if err != nil {
+			return fmt.Errorf("repositories: redis: RotateToken: Run: %w", err)
+		}
+	}
+
+	switch rotated {
+	case -1:
+		return fmt.Errorf("repositories: redis: RotateToken: %w", models.ErrNotValidTokens)
+	case 0:
+		return fmt.Errorf("repositories: redis: RotateToken: id %s already stored", newId)
+	case _changed:
+		return fmt.Errorf("repositories: redis: RotateToken: %w", errChanged)
+	}
+
+	return nil
+}
+
+func (r *Repos) GetIdempotency(ctx context.Context, key string) (_ models.Idempotency, err error) {
+	ctx, span := startSpan(ctx, "repositories.GetIdempotency", "HGETALL")
+	defer func() { tracing.End(span, err) }()
+
+	idemKey := r.idempotencyKey(key)
+
+	var fieldsCmd *goredis.MapStringStringCmd
+	var ttlCmd *goredis.DurationCmd
+
+	if _, err := r.Client.Pipelined(ctx, func(p goredis.Pipeliner) error {
+		fieldsCmd = p.HGetAll(ctx, idemKey)
+		ttlCmd = p.PTTL(ctx, idemKey)
+
+		return nil
+	}); err != nil {
+		return models.Idempotency{}, fmt.Errorf("repositories: redis: GetIdempotency: Pipelined: %w", err)
+	}
+
+	fields, ttl := fieldsCmd.Val(), ttlCmd.Val()
+
+	if len(fields) == 0 || ttl <= 0 {
+		return models.Idempotency{}, fmt.Errorf("repositories: redis: GetIdempotency: %w", models.ErrNotValidTokens)
+	}
+
+	return models.Idempotency{
+		Key:         key,
+		RequestHash: fields["request_hash"],
+		SessionId:   fields["session_id"],
+		Response:    fields["response"],
+		ExpiresAt:   time.Now().Add(ttl),
+	}, nil
+}
+
+func (r *Repos) GetGrace(ctx context.Context, id string) (_ models.Grace, err error) {
+	ctx, span := startSpan(ctx, "repositories.GetGrace", "HGETALL")
+	defer func() { tracing.End(span, err) }()
+
+	key := r.graceKey(id)
+
+	var fieldsCmd *goredis.MapStringStringCmd
+	var ttlCmd *goredis.DurationCmd
+
+	if _, err := r.Client.Pipelined(ctx, func(p goredis.Pipeliner) error {
+		fieldsCmd = p.HGetAll(ctx, key)
+		ttlCmd = p.PTTL(ctx, key)
+
+		return nil
+	}); err != nil {
+		return models.Grace{}, fmt.Errorf("repositories: redis: GetGrace: Pipelined: %w", err)
+	}
+
+	fields, ttl := fieldsCmd.Val(), ttlCmd.Val()
+
+	if len(fields) == 0 || ttl <= 0 {
+		return models.Grace{}, fmt.Errorf("repositories: redis: GetGrace: %w", models.ErrNotValidTokens)
+	}
+
+	return models.Grace{
+		Token:     fields["token"],
+		Successor: fields["successor"],
+		ExpiresAt: time.Now().Add(ttl),
+	}, nil
+}
+
+func (r *Repos) ListSessions(ctx context.Context, userId string) (_ []models.Session, err error) {
+	ctx, span := startSpan(ctx, "repositories.ListSessions", "SMEMBERS")
+	defer func() { tracing.End(span, err) }()
+
+	ids, err := r.Client.SMembers(ctx, r.userKey(userId)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("repositories: redis: ListSessions: SMembers: %w", err)
+	}
+
+	cmds := make([]*goredis.StringCmd, len(ids))
+
+	if _, err := r.Client.Pipelined(ctx, func(p goredis.Pipeliner) error {
+		for i, id := range ids {
+			cmds[i] = p.HGet(ctx, r.tokenKey(id), "created_at")
+		}
+
+		return nil
+	}); err != nil && !errors.Is(err, goredis.Nil) {
+		return nil, fmt.Errorf("repositories: redis: ListSessions: Pipelined: %w", err)
+	}
+
+	sessions := make([]models.Session, 0, len(ids))
+	expired := make([]any, 0)
+
+	for i, cmd := range cmds {
+		createdAt, err := cmd.Int64()
+		if err != nil {
+			if errors.Is(err, goredis.Nil) {
+				expired = append(expired, ids[i])
+				continue
+			}
+
+			return nil, fmt.Errorf("repositories: redis: ListSessions: Int64: %w", err)
+		}
+
+		sessions = append(sessions, models.Session{
+			Id:        ids[i],
+			UserId:    userId,
+			CreatedAt: time.UnixMicro(createdAt).UTC(),
+		})
+	}
+
+	if len(expired) > 0 {
+		if err := r.Client.SRem(ctx, r.userKey(userId), expired...).Err(); err != nil {
+			return nil, fmt.Errorf("repositories: redis: ListSessions: SRem: %w", err)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+
+	return sessions, nil
+}
+
+func (r *Repos) DestroyUserTokens(ctx context.Context, userId string) (_ int64, err error) {
+	ctx, span := startSpan(ctx, "repositories.DestroyUserTokens", "EVALSHA")
+	defer func() { tracing.End(span, err) }()
+
+	ids, err := r.Client.SMembers(ctx, r.userKey(userId)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("repositories: redis: DestroyUserTokens: SMembers: %w", err)
+	}
+
+	n, err := r.destroyAll(ctx, ids, userId)
+	if err != nil {
+		return n, fmt.Errorf("repositories: redis: DestroyUserTokens: %w", err)
+	}
+
+	return n, nil
+}
+
+func (r *Repos) DestroyTokensBefore(ctx context.Context, before time.Time) (_ int64, err error) {
+	ctx, span := startSpan(ctx, "repositories.DestroyTokensBefore", "EVALSHA")
+	defer func() { tracing.End(span, err) }()
+
+	ids, err := r.Client.ZRangeByScore(ctx, r.createdKey(), &goredis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(before.UnixMicro(), 10),
+	}).Result()
+	if err != nil {
+		return 0, fmt.Errorf("repositories: redis: DestroyTokensBefore: ZRangeByScore: %w", err)
+	}
+
+	n, err := r.destroyAll(ctx, ids, "")
+	if err != nil {
+		return n, fmt.Errorf("repositories: redis: DestroyTokensBefore: %w", err)
+	}
+
+	return n, nil
+}
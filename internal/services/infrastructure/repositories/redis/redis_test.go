@@ -0,0 +1,138 @@
+package redis_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/v1adhope/auth-service/internal/models"
+	repos "github.com/v1adhope/auth-service/internal/services/infrastructure/repositories/redis"
+	"github.com/v1adhope/auth-service/internal/testhelpers"
+	"github.com/v1adhope/auth-service/pkg/redis"
+)
+
+func setUp(t *testing.T, opts ...repos.Option) (*repos.Repos, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+
+	driver, err := redis.Build(context.Background(), redis.WithUrl("redis://"+mr.Addr()))
+	require.NoError(t, err)
+	t.Cleanup(driver.Close)
+
+	return repos.New(driver, opts...), mr
+}
+
+func TestConformance(t *testing.T) {
+	testhelpers.WhitelistSuite{
+		New: func(t *testing.T) testhelpers.Whitelist {
+			sut, _ := setUp(t, repos.WithTtl(time.Hour))
+			return sut
+		},
+		Ttl: time.Hour,
+	}.Run(t)
+}
+
+func TestConformanceWithoutTtl(t *testing.T) {
+	testhelpers.WhitelistSuite{
+		New: func(t *testing.T) testhelpers.Whitelist {
+			sut, _ := setUp(t)
+			return sut
+		},
+	}.Run(t)
+}
+
+func TestIndexesCleanedAfterExpiry(t *testing.T) {
+	ctx := context.Background()
+	sut, mr := setUp(t, repos.WithTtl(time.Minute))
+	userId := uuid.NewString()
+	id := uuid.NewString()
+
+	require.NoError(t, sut.StoreToken(ctx, id, userId, "hashed", time.Now()))
+
+	mr.FastForward(2 * time.Minute)
+
+	sessions, err := sut.ListSessions(ctx, userId)
+
+	assert.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	n, err := sut.DestroyTokensBefore(ctx, time.Now())
+
+	assert.NoError(t, err)
+	assert.Zero(t, n)
+	assert.False(t, mr.Exists("{auth_whitelist}:user:"+userId))
+	assert.False(t, mr.Exists("{auth_whitelist}:created"))
+}
+
+func TestCreatedTrimmedOnWrite(t *testing.T) {
+	ctx := context.Background()
+	sut, mr := setUp(t, repos.WithTtl(time.Minute))
+	now := time.Now()
+	expired, stored, other, rotated := uuid.NewString(), uuid.NewString(), uuid.NewString(), uuid.NewString()
+
+	require.NoError(t, sut.StoreToken(ctx, expired, uuid.NewString(), "hashed", now.Add(-2*time.Minute)))
+	require.NoError(t, sut.StoreToken(ctx, stored, uuid.NewString(), "hashed", now.Add(-30*time.Second)))
+	require.NoError(t, sut.StoreToken(ctx, other, uuid.NewString(), "hashed", now.Add(-30*time.Second)))
+
+	members, err := mr.ZMembers("{auth_whitelist}:created")
+
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{stored, other}, members)
+
+	require.NoError(t, sut.RotateToken(ctx, stored, "hashed", rotated, uuid.NewString(), "new", now.Add(time.Minute), models.Grace{}))
+
+	members, err = mr.ZMembers("{auth_whitelist}:created")
+
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{rotated}, members)
+}
+
+func TestKeysShareHashTag(t *testing.T) {
+	tcs := []struct {
+		key      string
+		opts     []repos.Option
+		expected string
+	}{
+		{
+			key:      "Default prefix",
+			expected: "{auth_whitelist}:",
+		},
+		{
+			key:      "Untagged prefix",
+			opts:     []repos.Option{repos.WithPrefix("sessions")},
+			expected: "{sessions}:",
+		},
+		{
+			key:      "Tagged prefix",
+			opts:     []repos.Option{repos.WithPrefix("auth:{sessions}")},
+			expected: "auth:{sessions}:",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.key, func(t *testing.T) {
+			ctx := context.Background()
+			sut, mr := setUp(t, append(tc.opts, repos.WithTtl(time.Minute))...)
+			now := time.Now()
+			id, newId, userId := uuid.NewString(), uuid.NewString(), uuid.NewString()
+
+			require.NoError(t, sut.StoreToken(ctx, id, userId, "hashed", now))
+			require.NoError(t, sut.RotateToken(ctx, id, "hashed", newId, userId, "new", now, models.Grace{
+				Token:     "token",
+				Successor: "successor",
+				ExpiresAt: now.Add(time.Minute),
+			}))
+
+			keys := mr.Keys()
+
+			require.Len(t, keys, 4)
+			for _, key := range keys {
+				assert.True(t, strings.HasPrefix(key, tc.expected), key)
+			}
+		})
+	}
+}
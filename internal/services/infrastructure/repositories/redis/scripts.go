@@ -0,0 +1,138 @@
+package redis
+
+import goredis "github.com/redis/go-redis/v9"
+
+// INFO: every token is a hash at {<prefix>}:token:<id>, indexed by
+// the {<prefix>}:user:<userId> set and the {<prefix>}:created sorted set
+// scored by creation time in microseconds, indexes are cleaned lazily
+// once redis expires the token itself, graces of rotated tokens
+// and idempotency records are hashes at {<prefix>}:grace:<id> and
+// {<prefix>}:idempotency:<key> expired by redis, a successor token keeps
+// the id of the grace leading to it in its grace field so destroying
+// the token drops the grace as well. Writes also trim
+// entries of the created set older than the ttl, so it stays bounded
+// without gc. The hash tag keeps every key in one cluster slot, scripts
+// get all keys they touch through KEYS, the ones named by token fields
+// are read beforehand and checked again inside the script
+
+var _storeScript = goredis.NewScript(`
+if redis.call('exists', KEYS[1]) == 1 then
+  return 0
+end
+
+redis.call('hset', KEYS[1], 'token', ARGV[3], 'user_id', ARGV[2], 'created_at', ARGV[4])
+redis.call('sadd', KEYS[2], ARGV[1])
+redis.call('zadd', KEYS[3], ARGV[4], ARGV[1])
+
+if ARGV[5] ~= '0' then
+  redis.call('pexpireat', KEYS[1], ARGV[5])
+  redis.call('pexpireat', KEYS[2], ARGV[5])
+  redis.call('zremrangebyscore', KEYS[3], '-inf', '(' .. ARGV[6])
+end
+
+return 1
+`)
+
+// INFO: returns the existing record as {request_hash, session_id, response, pttl}
+// without storing anything, {} once both the record and the token are stored
+// and -1 if the token id is taken
+var _storeIdempotentScript = goredis.NewScript(`
+local existing = redis.call('hmget', KEYS[4], 'request_hash', 'session_id', 'response')
+if existing[1] then
+  table.insert(existing, redis.call('pttl', KEYS[4]))
+  return existing
+end
+
+if redis.call('exists', KEYS[1]) == 1 then
+  return -1
+end
+
+redis.call('hset', KEYS[1], 'token', ARGV[3], 'user_id', ARGV[2], 'created_at', ARGV[4])
+redis.call('sadd', KEYS[2], ARGV[1])
+redis.call('zadd', KEYS[3], ARGV[4], ARGV[1])
+
+if ARGV[5] ~= '0' then
+  redis.call('pexpireat', KEYS[1], ARGV[5])
+  redis.call('pexpireat', KEYS[2], ARGV[5])
+  redis.call('zremrangebyscore', KEYS[3], '-inf', '(' .. ARGV[10])
+end
+
+redis.call('hset', KEYS[4], 'request_hash', ARGV[6], 'session_id', ARGV[7], 'response', ARGV[8])
+redis.call('pexpireat', KEYS[4], ARGV[9])
+
+return {}
+`)
+
+// INFO: returns -1 if the old token isn't stored, 0 if the new id is taken
+// and -2 if its owner or grace changed since they were read
+var _rotateScript = goredis.NewScript(`
+local fields = redis.call('hmget', KEYS[1], 'user_id', 'token', 'grace')
+if not fields[1] or fields[2] ~= ARGV[1] then
+  return -1
+end
+
+if fields[1] ~= ARGV[12] or (fields[3] or '') ~= ARGV[13] then
+  return -2
+end
+
+if redis.call('exists', KEYS[2]) == 1 then
+  return 0
+end
+
+if ARGV[13] ~= '' then
+  redis.call('del', KEYS[7])
+end
+
+redis.call('del', KEYS[1])
+redis.call('srem', KEYS[6], ARGV[2])
+redis.call('zrem', KEYS[4], ARGV[2])
+
+redis.call('hset', KEYS[2], 'token', ARGV[5], 'user_id', ARGV[4], 'created_at', ARGV[6])
+redis.call('sadd', KEYS[3], ARGV[3])
+redis.call('zadd', KEYS[4], ARGV[6], ARGV[3])
+
+if ARGV[7] ~= '0' then
+  redis.call('pexpireat', KEYS[2], ARGV[7])
+  redis.call('pexpireat', KEYS[3], ARGV[7])
+  redis.call('zremrangebyscore', KEYS[4], '-inf', '(' .. ARGV[11])
+end
+
+if ARGV[10] ~= '0' then
+  redis.call('hset', KEYS[5], 'token', ARGV[8], 'successor', ARGV[9])
+  redis.call('pexpireat', KEYS[5], ARGV[10])
+  redis.call('hset', KEYS[2], 'grace', ARGV[2])
+end
+
+return 1
+`)
+
+// INFO: KEYS[1] is the created set followed by the token, owner set and grace
+// keys of every token, ARGV holds its id, owner and grace id, an empty owner
+// or grace leaves the key unused. Returns the number of destroyed tokens
+// or -2 if a stored token no longer matches what was read
+var _destroyScript = goredis.NewScript(`
+for i = 2, #KEYS, 3 do
+  local fields = redis.call('hmget', KEYS[i], 'user_id', 'grace')
+  if fields[1] and (fields[1] ~= ARGV[i] or (fields[2] or '') ~= ARGV[i + 1]) then
+    return -2
+  end
+end
+
+local n = 0
+
+for i = 2, #KEYS, 3 do
+  if ARGV[i + 1] ~= '' then
+    redis.call('del', KEYS[i + 2])
+  end
+
+  n = n + redis.call('del', KEYS[i])
+
+  if ARGV[i] ~= '' then
+    redis.call('srem', KEYS[i + 1], ARGV[i - 1])
+  end
+
+  redis.call('zrem', KEYS[1], ARGV[i - 1])
+end
+
+return n
+`)
@@ -0,0 +1,165 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/v1adhope/auth-service/internal/models"
+	"github.com/v1adhope/auth-service/pkg/tracing"
+)
+
+func (r *Repos) ListSessions(ctx context.Context, userId string) (_ []models.Session, err error) {
+	ctx, span := startSpan(ctx, "repositories.ListSessions", "SELECT", "auth_whitelist")
+	defer func() { tracing.End(span, err) }()
+
+	sql, args, err := r.Builder.Select("id", "user_id", "created_at").
+		From("auth_whitelist").
+		Where(squirrel.And{
+			squirrel.Eq{"user_id": userId},
+			r.notExpired(time.Now()),
+		}).
+		OrderBy("created_at").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("repositories: sessions: ListSessions: ToSql: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("repositories: sessions: ListSessions: Query: %w", err)
+	}
+
+	sessions, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Session, error) {
+		s := models.Session{}
+		err := row.Scan(&s.Id, &s.UserId, &s.CreatedAt)
+
+		return s, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("repositories: sessions: ListSessions: CollectRows: %w", err)
+	}
+
+	return sessions, nil
+}
+
+func (r *Repos) DestroyUserTokens(ctx context.Context, userId string) (_ int64, err error) {
+	ctx, span := startSpan(ctx, "repositories.DestroyUserTokens", "DELETE", "auth_whitelist")
+	defer func() { tracing.End(span, err) }()
+
+	// INFO: expired rows are left to gc so the count matches ListSessions
+	sql, args, err := r.Builder.Delete("auth_whitelist").
+		Where(squirrel.And{
+			squirrel.Eq{"user_id": userId},
+			r.notExpired(time.Now()),
+		}).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("repositories: sessions: DestroyUserTokens: ToSql: %w", err)
+	}
+
+	var destroyed []string
+
+	err = r.InTx(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, sql, args...)
+		if err != nil {
+			return fmt.Errorf("repositories: sessions: DestroyUserTokens: Query: %w", err)
+		}
+
+		destroyed, err = pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			return fmt.Errorf("repositories: sessions: DestroyUserTokens: CollectRows: %w", err)
+		}
+
+		if len(destroyed) == 0 {
+			return nil
+		}
+
+		// INFO: graces leading to the destroyed tokens would hand them out again
+		graceSql, graceArgs, err := r.Builder.Delete("auth_grace").
+			Where(squirrel.Eq{
+				"successor_id": destroyed,
+			}).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("repositories: sessions: DestroyUserTokens: grace: ToSql: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, graceSql, graceArgs...); err != nil {
+			return fmt.Errorf("repositories: sessions: DestroyUserTokens: grace: Exec: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(destroyed)), nil
+}
+
+// INFO: expired grace and idempotency records and graces leading to the destroyed
+// tokens are dropped in the same transaction, issuance and rotation leave them behind for gc
+func (r *Repos) DestroyTokensBefore(ctx context.Context, before time.Time) (_ int64, err error) {
+	ctx, span := startSpan(ctx, "repositories.DestroyTokensBefore", "DELETE", "auth_whitelist")
+	defer func() { tracing.End(span, err) }()
+
+	sql, args, err := r.Builder.Delete("auth_whitelist").
+		Where(squirrel.LtOrEq{
+			"created_at": before,
+		}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("repositories: sessions: DestroyTokensBefore: ToSql: %w", err)
+	}
+
+	now := time.Now()
+
+	graceSql, graceArgs, err := r.Builder.Delete("auth_grace").
+		Where(squirrel.Or{
+			squirrel.LtOrEq{"expires_at": now},
+			squirrel.Expr("successor_id IN (SELECT id FROM auth_whitelist WHERE created_at <= ?)", before),
+		}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("repositories: sessions: DestroyTokensBefore: grace: ToSql: %w", err)
+	}
+
+	idemSql, idemArgs, err := r.Builder.Delete("auth_idempotency").
+		Where(squirrel.LtOrEq{
+			"expires_at": now,
+		}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("repositories: sessions: DestroyTokensBefore: idempotency: ToSql: %w", err)
+	}
+
+	var destroyed int64
+
+	err = r.InTx(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, graceSql, graceArgs...); err != nil {
+			return fmt.Errorf("repositories: sessions: DestroyTokensBefore: grace: Exec: %w", err)
+		}
+
+		tag, err := tx.Exec(ctx, sql, args...)
+		if err != nil {
+			return fmt.Errorf("repositories: sessions: DestroyTokensBefore: Exec: %w", err)
+		}
+
+		destroyed = tag.RowsAffected()
+
+		if _, err := tx.Exec(ctx, idemSql, idemArgs...); err != nil {
+			return fmt.Errorf("repositories: sessions: DestroyTokensBefore: idempotency: Exec: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return destroyed, nil
+}
@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/v1adhope/auth-service/internal/models"
+	"github.com/v1adhope/auth-service/pkg/tracing"
+)
+
+// INFO: user directory backed by the auth_users table
+func (r *Repos) GetUser(ctx context.Context, userId string) (_ models.User, err error) {
+	ctx, span := startSpan(ctx, "repositories.GetUser", "SELECT", "auth_users")
+	defer func() { tracing.End(span, err) }()
+
+	sql, args, err := r.Builder.Select("id", "status", "email", "roles").
+		From("auth_users").
+		Where(squirrel.Eq{
+			"id": userId,
+		}).
+		ToSql()
+	if err != nil {
+		return models.User{}, fmt.Errorf("repositories: users: GetUser: ToSql: %w", err)
+	}
+
+	user := models.User{}
+
+	if err := r.Pool.QueryRow(ctx, sql, args...).Scan(&user.Id, &user.Status, &user.Email, &user.Roles); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.User{}, fmt.Errorf("repositories: users: GetUser: %w", models.ErrUserNotFound)
+		}
+
+		return models.User{}, fmt.Errorf("repositories: users: GetUser: Scan: %w", err)
+	}
+
+	return user, nil
+}
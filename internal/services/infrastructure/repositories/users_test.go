@@ -0,0 +1,56 @@
+package repositories_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/v1adhope/auth-service/internal/models"
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/repositories"
+	"github.com/v1adhope/auth-service/internal/testhelpers"
+	"github.com/v1adhope/auth-service/pkg/postgresql"
+)
+
+func TestGetUser(t *testing.T) {
+	ctx := context.Background()
+
+	pgC, err := testhelpers.BuildContainer(ctx)
+	if err != nil {
+		t.Skipf("postgres container: %v", err)
+	}
+	t.Cleanup(func() { pgC.Terminate(ctx) })
+
+	require.NoError(t, pgC.MigrateUp())
+
+	postgres, err := postgresql.Build(ctx, postgresql.WithConnStr(pgC.ConnStr))
+	require.NoError(t, err)
+	t.Cleanup(postgres.Close)
+
+	_, err = postgres.Pool.Exec(ctx, `insert into auth_users(id, status, email, roles) values
+		('adb21fec-7892-416a-bbfc-9b2d77e8db4a', 'active', 'user@example.com', '{admin}'),
+		('0f58a78d-0b7c-4e1a-9cc3-6a3c1b9e8d22', 'locked', '', '{}')`)
+	require.NoError(t, err)
+
+	repo := repositories.New(postgres)
+
+	sut, err := repo.GetUser(ctx, "adb21fec-7892-416a-bbfc-9b2d77e8db4a")
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.User{
+		Id:     "adb21fec-7892-416a-bbfc-9b2d77e8db4a",
+		Status: models.UserActive,
+		Email:  "user@example.com",
+		Roles:  []string{"admin"},
+	}, sut)
+
+	sut, err = repo.GetUser(ctx, "0f58a78d-0b7c-4e1a-9cc3-6a3c1b9e8d22")
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.UserLocked, sut.Status)
+	assert.Empty(t, sut.Roles)
+
+	_, err = repo.GetUser(ctx, "5b0e7c2a-3f4d-4c8e-9a1b-2d6f8e0c4a7b")
+
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+}
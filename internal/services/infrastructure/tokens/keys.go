@@ -0,0 +1,107 @@
+package tokens
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	_prefixHex    = "hex:"
+	_prefixBase64 = "base64:"
+
+	// INFO: RFC 7518 3.2, an HMAC key at least as long as the hash output
+	_minAccessKeyLen = 64
+
+	// INFO: shannon estimate per byte, random keys in any common
+	// alphabet stay well above, repeated or dictionary-like ones fall below
+	_minKeyEntropy = 3.0
+)
+
+// INFO: HS512 secret, raw or "hex:" / "base64:" prefixed
+func ParseAccessKey(s string) ([]byte, error) {
+	key, err := decodeKey(s)
+	if err != nil {
+		return nil, fmt.Errorf("tokens: keys: ParseAccessKey: %w", err)
+	}
+
+	if len(key) < _minAccessKeyLen {
+		return nil, fmt.Errorf("tokens: keys: ParseAccessKey: HS512 needs at least %d bytes, got %d", _minAccessKeyLen, len(key))
+	}
+
+	if err := checkEntropy(key); err != nil {
+		return nil, fmt.Errorf("tokens: keys: ParseAccessKey: %w", err)
+	}
+
+	return key, nil
+}
+
+// INFO: AES-GCM key, raw or "hex:" / "base64:" prefixed
+func ParseRefreshKey(s string) ([]byte, error) {
+	key, err := decodeKey(s)
+	if err != nil {
+		return nil, fmt.Errorf("tokens: keys: ParseRefreshKey: %w", err)
+	}
+
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, fmt.Errorf("tokens: keys: ParseRefreshKey: AES-GCM needs 16, 24 or 32 bytes, got %d", len(key))
+	}
+
+	if err := checkEntropy(key); err != nil {
+		return nil, fmt.Errorf("tokens: keys: ParseRefreshKey: %w", err)
+	}
+
+	return key, nil
+}
+
+func decodeKey(s string) ([]byte, error) {
+	switch {
+	case s == "":
+		return nil, fmt.Errorf("key not defined")
+	case strings.HasPrefix(s, _prefixHex):
+		key, err := hex.DecodeString(strings.TrimPrefix(s, _prefixHex))
+		if err != nil {
+			return nil, fmt.Errorf("not valid hex key: %w", err)
+		}
+
+		return key, nil
+	case strings.HasPrefix(s, _prefixBase64):
+		encoded := strings.TrimRight(strings.TrimPrefix(s, _prefixBase64), "=")
+
+		key, err := base64.RawStdEncoding.DecodeString(encoded)
+		if err != nil {
+			if key, err = base64.RawURLEncoding.DecodeString(encoded); err != nil {
+				return nil, fmt.Errorf("not valid base64 key: %w", err)
+			}
+		}
+
+		return key, nil
+	}
+
+	return []byte(s), nil
+}
+
+func checkEntropy(key []byte) error {
+	counts := map[byte]int{}
+
+	for _, b := range key {
+		counts[b]++
+	}
+
+	entropy := 0.0
+
+	for _, n := range counts {
+		p := float64(n) / float64(len(key))
+		entropy -= p * math.Log2(p)
+	}
+
+	if entropy < _minKeyEntropy {
+		return fmt.Errorf("key looks low entropy (%.1f bits per byte), generate a random one", entropy)
+	}
+
+	return nil
+}
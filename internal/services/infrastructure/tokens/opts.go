@@ -22,6 +22,13 @@ func WithRefreshKey(k string) Option {
 	}
 }
 
+// INFO: should cover the refresh token lifetime, i.e. the storage ttl
+func WithRefreshKeyRetention(r time.Duration) Option {
+	return func(t *Tokens) {
+		t.refresh.retention = r
+	}
+}
+
 func WithIssuer(i string) Option {
 	return func(t *Tokens) {
 		t.issuer = i
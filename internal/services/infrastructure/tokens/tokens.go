@@ -1,7 +1,11 @@
 package tokens
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,21 +13,37 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/v1adhope/auth-service/internal/models"
 	"github.com/v1adhope/auth-service/pkg/serialization"
+	"github.com/v1adhope/auth-service/pkg/tracing"
+	"go.opentelemetry.io/otel"
 )
 
+var tracer = otel.Tracer("github.com/v1adhope/auth-service/internal/services/infrastructure/tokens")
+
 type Tokens struct {
+	// INFO: guards the keys, they can be swapped by SetKeys at runtime
+	mu      sync.RWMutex
 	access  Acccess
 	refresh Refresh
 	issuer  string
 }
 
 type Acccess struct {
-	ttl time.Duration
-	key []byte
+	ttl     time.Duration
+	key     []byte
+	retired []retiredKey
 }
 
 type Refresh struct {
-	key []byte
+	// INFO: how long a replaced key still opens refresh tokens, zero keeps it for good
+	retention time.Duration
+	key       []byte
+	retired   []retiredKey
+}
+
+// INFO: a replaced key, kept while tokens issued with it may still be presented
+type retiredKey struct {
+	key   []byte
+	until time.Time
 }
 
 type accessClaims struct {
@@ -31,8 +51,9 @@ type accessClaims struct {
 	jwt.RegisteredClaims
 }
 
-// INFO: panic if access or refresh keys not defined
-func New(opts ...Option) *Tokens {
+// INFO: keys go through ParseAccessKey and ParseRefreshKey,
+// problems with both are returned together
+func New(opts ...Option) (*Tokens, error) {
 	t := &Tokens{
 		access: Acccess{
 			ttl: time.Duration(20 * time.Second),
@@ -45,19 +66,23 @@ func New(opts ...Option) *Tokens {
 		opt(t)
 	}
 
-	if t.access.key == nil {
-		panic("tokens: define access key")
-	}
+	accessKey, accessErr := ParseAccessKey(string(t.access.key))
+	refreshKey, refreshErr := ParseRefreshKey(string(t.refresh.key))
 
-	if t.refresh.key == nil {
-		panic("tokens: define refresh key")
+	if err := errors.Join(accessErr, refreshErr); err != nil {
+		return nil, err
 	}
 
-	return t
+	t.access.key, t.refresh.key = accessKey, refreshKey
+
+	return t, nil
 }
 
 // INFO: not invariant values might be used as deps for testing
-func (t *Tokens) GeneratePair(ip string, userId string) (models.TokenPair, error) {
+func (t *Tokens) GeneratePair(ctx context.Context, ip string, userId string) (_ models.TokenPair, err error) {
+	_, span := tracer.Start(ctx, "tokens.GeneratePair")
+	defer func() { tracing.End(span, err) }()
+
 	id, err := uuid.NewV6()
 	if err != nil {
 		return models.TokenPair{}, fmt.Errorf("tokens: tokens: GeneratePair: NewV6: %w", err)
@@ -73,7 +98,88 @@ func (t *Tokens) GeneratePair(ip string, userId string) (models.TokenPair, error
 		return models.TokenPair{}, err
 	}
 
-	return models.TokenPair{id.String(), accessT, refreshT}, nil
+	return models.TokenPair{
+		Id:      id.String(),
+		Access:  accessT,
+		Refresh: refreshT,
+	}, nil
+}
+
+// INFO: new tokens use the given keys, tokens issued with the replaced ones stay
+// valid for the access ttl and the refresh retention, however many swaps happen
+// meanwhile. Nothing changes if either key is not valid
+func (t *Tokens) SetKeys(accessKey, refreshKey string) error {
+	access, accessErr := ParseAccessKey(accessKey)
+	refresh, refreshErr := ParseRefreshKey(refreshKey)
+
+	if err := errors.Join(accessErr, refreshErr); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+
+	t.access.key, t.access.retired = rotate(t.access.key, access, t.access.retired, now, t.access.ttl)
+	t.refresh.key, t.refresh.retired = rotate(t.refresh.key, refresh, t.refresh.retired, now, t.refresh.retention)
+
+	return nil
+}
+
+// INFO: retires cur unless it's kept, drops keys past their retention
+// and the next key itself, a key rotated back in isn't tried twice
+func rotate(cur, next []byte, retired []retiredKey, now time.Time, retention time.Duration) ([]byte, []retiredKey) {
+	kept := make([]retiredKey, 0, len(retired)+1)
+
+	if !bytes.Equal(cur, next) {
+		until := time.Time{}
+		if retention > 0 {
+			until = now.Add(retention)
+		}
+
+		kept = append(kept, retiredKey{cur, until})
+	}
+
+	for _, r := range retired {
+		if r.usable(now) && !bytes.Equal(r.key, next) && !bytes.Equal(r.key, cur) {
+			kept = append(kept, r)
+		}
+	}
+
+	return next, kept
+}
+
+func (r retiredKey) usable(now time.Time) bool {
+	return r.until.IsZero() || now.Before(r.until)
+}
+
+// INFO: the current key first, then the retired ones still usable
+func (t *Tokens) accessKeys() [][]byte {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return keyRing(t.access.key, t.access.retired)
+}
+
+func (t *Tokens) refreshKeys() [][]byte {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return keyRing(t.refresh.key, t.refresh.retired)
+}
+
+func keyRing(key []byte, retired []retiredKey) [][]byte {
+	now := time.Now()
+	keys := [][]byte{key}
+
+	for _, r := range retired {
+		if r.usable(now) {
+			keys = append(keys, r.key)
+		}
+	}
+
+	return keys
 }
 
 func (t *Tokens) generateAccess(id string, ip string, userId string) (string, error) {
@@ -88,7 +194,9 @@ func (t *Tokens) generateAccess(id string, ip string, userId string) (string, er
 		},
 	}
 
-	accessT, err := jwt.NewWithClaims(jwt.SigningMethodHS512, claims).SignedString(t.access.key)
+	key := t.accessKeys()[0]
+
+	accessT, err := jwt.NewWithClaims(jwt.SigningMethodHS512, claims).SignedString(key)
 	if err != nil {
 		return "", fmt.Errorf("tokens: tokens: generateAccess: SignedString: %w", err)
 	}
@@ -97,7 +205,9 @@ func (t *Tokens) generateAccess(id string, ip string, userId string) (string, er
 }
 
 func (t *Tokens) generateRefresh(id string) (string, error) {
-	ciphertext, err := serialization.EncryptByGcm([]byte(id), t.refresh.key)
+	key := t.refreshKeys()[0]
+
+	ciphertext, err := serialization.EncryptByGcm([]byte(id), key)
 	if err != nil {
 		return "", err
 	}
@@ -105,16 +215,23 @@ func (t *Tokens) generateRefresh(id string) (string, error) {
 	return string(ciphertext), err
 }
 
-func (t *Tokens) ExtractRefreshPayload(token string) (string, error) {
-	text, err := serialization.DecryptByGcm([]byte(token), t.refresh.key)
-	if err != nil {
-		return "", fmt.Errorf("tokens: tokens: ExtractRefreshPayload: DecryptByGcm: %w", models.ErrNotValidTokens)
+func (t *Tokens) ExtractRefreshPayload(ctx context.Context, token string) (_ string, err error) {
+	_, span := tracer.Start(ctx, "tokens.ExtractRefreshPayload")
+	defer func() { tracing.End(span, err) }()
+
+	for _, key := range t.refreshKeys() {
+		if text, err := serialization.DecryptByGcm([]byte(token), key); err == nil {
+			return string(text), nil
+		}
 	}
 
-	return string(text), nil
+	return "", fmt.Errorf("tokens: tokens: ExtractRefreshPayload: DecryptByGcm: %w", models.ErrNotValidTokens)
 }
 
-func (t *Tokens) ExtractAccessPayload(token string) (userId, id, ip string, err error) {
+func (t *Tokens) ExtractAccessPayload(ctx context.Context, token string) (userId, id, ip string, err error) {
+	_, span := tracer.Start(ctx, "tokens.ExtractAccessPayload")
+	defer func() { tracing.End(span, err) }()
+
 	claims, err := t.parseAccess(token)
 	if err != nil {
 		return "", "", "", err
@@ -131,7 +248,17 @@ func (t *Tokens) parseAccess(target string) (accessClaims, error) {
 			return nil, fmt.Errorf("tokens: tokens: parseAccess: ParseWithClaims: %w", models.ErrNotValidTokens)
 		}
 
-		return []byte(t.access.key), nil
+		keys := t.accessKeys()
+		if len(keys) == 1 {
+			return keys[0], nil
+		}
+
+		set := jwt.VerificationKeySet{Keys: make([]jwt.VerificationKey, 0, len(keys))}
+		for _, key := range keys {
+			set.Keys = append(set.Keys, key)
+		}
+
+		return set, nil
 	})
 	if err != nil {
 		return accessClaims{}, fmt.Errorf("tokens: tokens: parseAccess: Parse: %w", err)
@@ -148,3 +275,26 @@ func (t *Tokens) parseAccess(target string) (accessClaims, error) {
 func (t *Tokens) extractUsefulClaims(claims accessClaims) (id, ip, userId string) {
 	return claims.ID, claims.Ip, claims.Subject
 }
+
+func (t *Tokens) Ready(ctx context.Context) error {
+	if len(t.accessKeys()[0]) == 0 || len(t.refreshKeys()[0]) == 0 {
+		return fmt.Errorf("tokens: tokens: Ready: keys not loaded")
+	}
+
+	return nil
+}
+
+// INFO: diagnostics only, claims are returned even if verification fails
+func (t *Tokens) InspectAccess(token string) (map[string]any, error) {
+	claims := jwt.MapClaims{}
+
+	if _, _, err := jwt.NewParser().ParseUnverified(token, claims); err != nil {
+		return nil, fmt.Errorf("tokens: tokens: InspectAccess: ParseUnverified: %w", models.ErrNotValidTokens)
+	}
+
+	if _, err := t.parseAccess(token); err != nil {
+		return claims, err
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,157 @@
+package tokens_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/v1adhope/auth-service/internal/models"
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/tokens"
+)
+
+const (
+	_accessKey  = "sOXgK2jwm7otKu2iq8uy8PN47DZ88T4EodChguRAZ5gUyifoHKU0u63BGlKnCQcW"
+	_refreshKey = "HC2fAkS4Lyfisrt4agCZgRU7eWPpFgbH"
+)
+
+func TestNew(t *testing.T) {
+	tcs := []struct {
+		key        string
+		access     string
+		refresh    string
+		expectedOk bool
+	}{
+		{
+			key:        "Raw keys",
+			access:     _accessKey,
+			refresh:    _refreshKey,
+			expectedOk: true,
+		},
+		{
+			key:        "Hex keys",
+			access:     "hex:" + hex.EncodeToString([]byte(_accessKey)),
+			refresh:    "hex:" + hex.EncodeToString([]byte(_refreshKey[:16])),
+			expectedOk: true,
+		},
+		{
+			key:        "Base64 keys",
+			access:     "base64:" + base64.StdEncoding.EncodeToString([]byte(_accessKey)),
+			refresh:    "base64:" + base64.RawURLEncoding.EncodeToString([]byte(_refreshKey[:24])),
+			expectedOk: true,
+		},
+		{
+			key:     "Short access key",
+			access:  "secret",
+			refresh: _refreshKey,
+		},
+		{
+			key:     "Low entropy access key",
+			access:  strings.Repeat("ab", 32),
+			refresh: _refreshKey,
+		},
+		{
+			key:     "Refresh key of wrong length",
+			access:  _accessKey,
+			refresh: _refreshKey[:20],
+		},
+		{
+			key:     "Not valid hex",
+			access:  _accessKey,
+			refresh: "hex:" + _refreshKey,
+		},
+		{
+			key:    "Without refresh key",
+			access: _accessKey,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.key, func(t *testing.T) {
+			_, err := tokens.New(
+				tokens.WithAccessKey(tc.access),
+				tokens.WithRefreshKey(tc.refresh),
+			)
+
+			if tc.expectedOk {
+				assert.NoError(t, err, tc.key)
+				return
+			}
+
+			assert.Error(t, err, tc.key)
+		})
+	}
+}
+
+func TestSetKeys(t *testing.T) {
+	ctx := context.Background()
+	tm, err := tokens.New(
+		tokens.WithAccessKey(_accessKey),
+		tokens.WithRefreshKey(_refreshKey),
+		tokens.WithRefreshKeyRetention(time.Hour),
+	)
+
+	assert.NoError(t, err)
+
+	old, err := tm.GeneratePair(ctx, "127.0.0.1", "adb21fec-7892-416a-bbfc-9b2d77e8db4a")
+
+	assert.NoError(t, err)
+
+	assert.NoError(t, tm.SetKeys("tm2bVHVQUgDFfw20UGbNOHEmnUjhUSeaRblT123m3RpKqeLpam3ZYPcd2CS2fA1J", "MFYXyzEeCVX9wbHbpagdDwCWyacwwLb7"))
+
+	assert.Error(t, tm.SetKeys("secret", "MFYXyzEeCVX9wbHbpagdDwCWyacwwLb7"))
+
+	assert.NoError(t, tm.SetKeys("EoSrawgoVEBlFxcWlT7Ogh1pg32Dc2stI68P02DxDSyGVggQxCQyuQorsXF7bhIb", "q8GEhGwJg54438e5VRaOpcQ3eSiSF2if"))
+
+	id, _, _, err := tm.ExtractAccessPayload(ctx, old.Access)
+
+	assert.NoError(t, err)
+	assert.Equal(t, old.Id, id)
+
+	id, err = tm.ExtractRefreshPayload(ctx, old.Refresh)
+
+	assert.NoError(t, err)
+	assert.Equal(t, old.Id, id)
+
+	fresh, err := tm.GeneratePair(ctx, "127.0.0.1", "adb21fec-7892-416a-bbfc-9b2d77e8db4a")
+
+	assert.NoError(t, err)
+
+	id, err = tm.ExtractRefreshPayload(ctx, fresh.Refresh)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fresh.Id, id)
+}
+
+func TestSetKeysRetention(t *testing.T) {
+	ctx := context.Background()
+	tm, err := tokens.New(
+		tokens.WithAccessKey(_accessKey),
+		tokens.WithRefreshKey(_refreshKey),
+		tokens.WithRefreshKeyRetention(time.Nanosecond),
+	)
+
+	assert.NoError(t, err)
+
+	old, err := tm.GeneratePair(ctx, "127.0.0.1", "adb21fec-7892-416a-bbfc-9b2d77e8db4a")
+
+	assert.NoError(t, err)
+
+	assert.NoError(t, tm.SetKeys(_accessKey, "MFYXyzEeCVX9wbHbpagdDwCWyacwwLb7"))
+
+	time.Sleep(time.Millisecond)
+
+	_, err = tm.ExtractRefreshPayload(ctx, old.Refresh)
+
+	assert.ErrorIs(t, err, models.ErrNotValidTokens)
+
+	assert.NoError(t, tm.SetKeys(_accessKey, _refreshKey))
+
+	id, err := tm.ExtractRefreshPayload(ctx, old.Refresh)
+
+	assert.NoError(t, err)
+	assert.Equal(t, old.Id, id)
+}
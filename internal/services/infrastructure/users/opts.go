@@ -0,0 +1,53 @@
+package users
+
+import (
+	"net/http"
+	"time"
+)
+
+type Option func(*Config)
+
+type Config struct {
+	Url    string
+	Token  string
+	Client *http.Client
+}
+
+// INFO: base url, the user id is appended as the last path segment
+func WithUrl(url string) Option {
+	return func(cfg *Config) {
+		cfg.Url = url
+	}
+}
+
+// INFO: sent as a bearer token when defined
+func WithToken(token string) Option {
+	return func(cfg *Config) {
+		cfg.Token = token
+	}
+}
+
+func WithHttpClient(c *http.Client) Option {
+	return func(cfg *Config) {
+		cfg.Client = c
+	}
+}
+
+// INFO: panic if url not defined
+func config(opts ...Option) Config {
+	cfg := Config{
+		Client: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if cfg.Url == "" {
+		panic("users: define url")
+	}
+
+	return cfg
+}
@@ -0,0 +1,76 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/v1adhope/auth-service/internal/models"
+)
+
+const _maxErrBody = 512
+
+// INFO: user directory behind an http callback, GET <url>/<userId>
+// answers 200 with a models.User as json or 404 for unknown users
+type Http struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func New(opts ...Option) *Http {
+	cfg := config(opts...)
+
+	return &Http{
+		url:    strings.TrimSuffix(cfg.Url, "/") + "/",
+		token:  cfg.Token,
+		client: cfg.Client,
+	}
+}
+
+func (h *Http) GetUser(ctx context.Context, userId string) (models.User, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.url+url.PathEscape(userId), nil)
+	if err != nil {
+		return models.User{}, fmt.Errorf("users: users: GetUser: NewRequest: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	if h.token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.token)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return models.User{}, fmt.Errorf("users: users: GetUser: Do: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return models.User{}, fmt.Errorf("users: users: GetUser: %w", models.ErrUserNotFound)
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, _maxErrBody))
+
+		return models.User{}, fmt.Errorf("users: users: GetUser: unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	user := models.User{}
+
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return models.User{}, fmt.Errorf("users: users: GetUser: Decode: %w", err)
+	}
+
+	if user.Id != "" && user.Id != userId {
+		return models.User{}, fmt.Errorf("users: users: GetUser: answered for %q instead of %q", user.Id, userId)
+	}
+
+	user.Id = userId
+
+	return user, nil
+}
@@ -0,0 +1,96 @@
+package users_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/v1adhope/auth-service/internal/models"
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/users"
+)
+
+func TestGetUser(t *testing.T) {
+	const userId = "adb21fec-7892-416a-bbfc-9b2d77e8db4a"
+
+	tcs := []struct {
+		key         string
+		status      int
+		body        string
+		expected    models.User
+		expectedErr error
+		expectedOk  bool
+	}{
+		{
+			key:    "Active",
+			status: http.StatusOK,
+			body:   `{"id":"` + userId + `","status":"active","email":"user@example.com","roles":["admin"]}`,
+			expected: models.User{
+				Id:     userId,
+				Status: models.UserActive,
+				Email:  "user@example.com",
+				Roles:  []string{"admin"},
+			},
+			expectedOk: true,
+		},
+		{
+			key:        "Without id",
+			status:     http.StatusOK,
+			body:       `{"status":"locked"}`,
+			expected:   models.User{Id: userId, Status: models.UserLocked},
+			expectedOk: true,
+		},
+		{
+			key:         "Not found",
+			status:      http.StatusNotFound,
+			expectedErr: models.ErrUserNotFound,
+		},
+		{
+			key:    "Other user",
+			status: http.StatusOK,
+			body:   `{"id":"0f58a78d-0b7c-4e1a-9cc3-6a3c1b9e8d22","status":"active"}`,
+		},
+		{
+			key:    "Unauthorized",
+			status: http.StatusUnauthorized,
+		},
+		{
+			key:    "Not json",
+			status: http.StatusOK,
+			body:   `<html>`,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.key, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/users/"+userId || r.Header.Get("Authorization") != "Bearer token" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+
+			sut, err := users.New(
+				users.WithUrl(srv.URL+"/users/"),
+				users.WithToken("token"),
+			).GetUser(context.Background(), userId)
+
+			if tc.expectedOk {
+				assert.NoError(t, err, tc.key)
+				assert.Equal(t, tc.expected, sut, tc.key)
+				return
+			}
+
+			assert.Error(t, err, tc.key)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr, tc.key)
+			}
+		})
+	}
+}
@@ -12,20 +12,46 @@ type Alerter interface {
 }
 
 type AuthRepo interface {
-	StoreToken(ctx context.Context, id, token string, now time.Time) error
+	StoreToken(ctx context.Context, id, userId, token string, now time.Time) error
 	GetToken(ctx context.Context, id string) (string, error)
+	// INFO: stores the token along with idem unless an unexpired record with idem.Key
+	// exists, that record is returned with stored false and the token isn't stored
+	StoreTokenIdempotent(ctx context.Context, id, userId, token string, now time.Time, idem models.Idempotency) (_ models.Idempotency, stored bool, err error)
+	// INFO: ErrNotValidTokens once the record expired
+	GetIdempotency(ctx context.Context, key string) (models.Idempotency, error)
 	DestroyToken(ctx context.Context, id string) error
+	// INFO: atomically replaces id with newId if id is still stored with token,
+	// ErrNotValidTokens otherwise, a grace with zero ExpiresAt isn't stored
+	RotateToken(ctx context.Context, id, token, newId, userId, newToken string, now time.Time, grace models.Grace) error
+	// INFO: ErrNotValidTokens once the grace expired
+	GetGrace(ctx context.Context, id string) (models.Grace, error)
 }
 
 type Hasher interface {
-	Do(target string) (string, error)
-	Check(hashedTarget, target string) error
+	Do(ctx context.Context, target string) (string, error)
+	Check(ctx context.Context, hashedTarget, target string) error
+	// INFO: true when hashedTarget was made with another mode or other costs
+	NeedsRehash(hashedTarget string) bool
+}
+
+type Meter interface {
+	TokenIssued()
+	TokenRefreshed()
+	TokenRevoked()
+	TokenReplayed()
+	RefreshFailed(reason string)
+	IpChanged()
 }
 
 type TokenManager interface {
-	GeneratePair(ip string, userId string) (models.TokenPair, error)
-	ExtractRefreshPayload(token string) (string, error)
-	ExtractAccessPayload(token string) (id, ip, userId string, err error)
+	GeneratePair(ctx context.Context, ip string, userId string) (models.TokenPair, error)
+	ExtractRefreshPayload(ctx context.Context, token string) (string, error)
+	ExtractAccessPayload(ctx context.Context, token string) (id, ip, userId string, err error)
+}
+
+// INFO: ErrUserNotFound for users it doesn't know
+type UserDirectory interface {
+	GetUser(ctx context.Context, userId string) (models.User, error)
 }
 
 type Validater interface {
@@ -0,0 +1,44 @@
+package services
+
+import "time"
+
+type Option func(*Config)
+
+type Config struct {
+	RefreshGrace   time.Duration
+	IdempotencyTtl time.Duration
+	Users          UserDirectory
+}
+
+// INFO: how long re-presenting a just rotated refresh token
+// returns its successor instead of failing, zero disables it
+func WithRefreshGrace(d time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.RefreshGrace = d
+	}
+}
+
+// INFO: how long an idempotency key replays the pair it issued,
+// zero issues a new pair on every call
+func WithIdempotencyTtl(d time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.IdempotencyTtl = d
+	}
+}
+
+// INFO: consulted on issuance and refresh, nil trusts any valid guid
+func WithUserDirectory(d UserDirectory) Option {
+	return func(cfg *Config) {
+		cfg.Users = d
+	}
+}
+
+func config(opts ...Option) Config {
+	cfg := Config{}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return cfg
+}
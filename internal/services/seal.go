@@ -0,0 +1,107 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/v1adhope/auth-service/internal/models"
+)
+
+const (
+	purposeGrace       = "grace"
+	purposeIdempotency = "idempotency"
+)
+
+// INFO: pairs kept in the storage for replays are sealed with a key derived
+// from a secret the storage never sees, the rotated refresh token for graces
+// and the client idempotency key for retried issuance
+func sealKey(purpose, secret string) []byte {
+	key := sha256.Sum256([]byte("auth-service " + purpose + "\x00" + secret))
+
+	return key[:]
+}
+
+type sealedPair struct {
+	Id      string `json:"id"`
+	Access  string `json:"access"`
+	Refresh string `json:"refresh"`
+}
+
+// INFO: the storage keys records by a hash so it can't derive the seal key,
+// the user is hashed in so the same key from two users never collides
+func idempotencyKeyHash(userId, key string) string {
+	sum := sha256.Sum256([]byte(userId + "\x00" + key))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func sealPair(purpose, secret string, tp models.TokenPair) (string, error) {
+	gcm, err := pairCipher(purpose, secret)
+	if err != nil {
+		return "", fmt.Errorf("services: seal: sealPair: %w", err)
+	}
+
+	plain, err := json.Marshal(sealedPair{tp.Id, tp.Access, tp.Refresh})
+	if err != nil {
+		return "", fmt.Errorf("services: seal: sealPair: Marshal: %w", err)
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("services: seal: sealPair: Read: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, plain, nil)), nil
+}
+
+func openPair(purpose, secret, sealed string) (models.TokenPair, error) {
+	gcm, err := pairCipher(purpose, secret)
+	if err != nil {
+		return models.TokenPair{}, fmt.Errorf("services: seal: openPair: %w", err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(data) < gcm.NonceSize() {
+		return models.TokenPair{}, fmt.Errorf("services: seal: openPair: DecodeString: %w", models.ErrNotValidTokens)
+	}
+
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return models.TokenPair{}, fmt.Errorf("services: seal: openPair: Open: %w", models.ErrNotValidTokens)
+	}
+
+	sp := sealedPair{}
+
+	if err := json.Unmarshal(plain, &sp); err != nil {
+		return models.TokenPair{}, fmt.Errorf("services: seal: openPair: Unmarshal: %w", err)
+	}
+
+	return models.TokenPair{
+		Id:      sp.Id,
+		Access:  sp.Access,
+		Refresh: sp.Refresh,
+	}, nil
+}
+
+func pairCipher(purpose, secret string) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(sealKey(purpose, secret))
+	if err != nil {
+		return nil, fmt.Errorf("NewCipher: %w", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("NewGCM: %w", err)
+	}
+
+	return gcm, nil
+}
@@ -6,11 +6,10 @@ import (
 	"time"
 
 	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
+	"github.com/v1adhope/auth-service/db"
 )
 
 var (
@@ -26,7 +25,7 @@ type PostgresContainer struct {
 	migrate *migrate.Migrate
 }
 
-func BuildContainer(ctx context.Context, migrationsSourceUrl string) (*PostgresContainer, error) {
+func BuildContainer(ctx context.Context) (*PostgresContainer, error) {
 	pgC, err := postgres.Run(
 		ctx,
 		_pgImage,
@@ -46,9 +45,9 @@ func BuildContainer(ctx context.Context, migrationsSourceUrl string) (*PostgresC
 		return nil, fmt.Errorf("testhelpers: postgres: BuildContainer: ConnectionString: %w", err)
 	}
 
-	m, err := migrate.New(migrationsSourceUrl, connStr)
+	m, err := db.NewMigrate(connStr)
 	if err != nil {
-		return nil, fmt.Errorf("testhelpers: postgres: BuildContainer: New: %w", err)
+		return nil, fmt.Errorf("testhelpers: postgres: BuildContainer: NewMigrate: %w", err)
 	}
 
 	return &PostgresContainer{
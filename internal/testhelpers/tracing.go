@@ -0,0 +1,28 @@
+package testhelpers
+
+import (
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var (
+	_spansOnce     sync.Once
+	_spansExporter = tracetest.NewInMemoryExporter()
+)
+
+// INFO: package level tracers delegate to the first global provider only,
+// so one in-memory provider is installed per test binary and reset on each call
+func RecordSpans() *tracetest.InMemoryExporter {
+	_spansOnce.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(_spansExporter)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+
+	_spansExporter.Reset()
+
+	return _spansExporter
+}
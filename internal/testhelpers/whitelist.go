@@ -0,0 +1,552 @@
+package testhelpers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/v1adhope/auth-service/internal/models"
+)
+
+type Whitelist interface {
+	StoreToken(ctx context.Context, id, userId, token string, now time.Time) error
+	StoreTokenIdempotent(ctx context.Context, id, userId, token string, now time.Time, idem models.Idempotency) (models.Idempotency, bool, error)
+	GetIdempotency(ctx context.Context, key string) (models.Idempotency, error)
+	GetToken(ctx context.Context, id string) (string, error)
+	DestroyToken(ctx context.Context, id string) error
+	RotateToken(ctx context.Context, id, token, newId, userId, newToken string, now time.Time, grace models.Grace) error
+	GetGrace(ctx context.Context, id string) (models.Grace, error)
+	ListSessions(ctx context.Context, userId string) ([]models.Session, error)
+	DestroyUserTokens(ctx context.Context, userId string) (int64, error)
+	DestroyTokensBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// INFO: behaviour every whitelist backend has to share
+type WhitelistSuite struct {
+	// INFO: returns an empty whitelist on every call
+	New func(t *testing.T) Whitelist
+	// INFO: ttl the whitelist was built with, zero skips expiry checks
+	Ttl time.Duration
+}
+
+func (s WhitelistSuite) Run(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	t.Run("Get stored", func(t *testing.T) {
+		sut := s.New(t)
+		id := uuid.NewString()
+
+		require.NoError(t, sut.StoreToken(ctx, id, uuid.NewString(), "hashed", now))
+
+		token, err := sut.GetToken(ctx, id)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "hashed", token)
+	})
+
+	t.Run("Get unknown", func(t *testing.T) {
+		sut := s.New(t)
+
+		_, err := sut.GetToken(ctx, uuid.NewString())
+
+		assert.ErrorIs(t, err, models.ErrNotValidTokens)
+	})
+
+	t.Run("Store duplicate", func(t *testing.T) {
+		sut := s.New(t)
+		id := uuid.NewString()
+
+		require.NoError(t, sut.StoreToken(ctx, id, uuid.NewString(), "first", now))
+
+		assert.Error(t, sut.StoreToken(ctx, id, uuid.NewString(), "second", now))
+
+		token, err := sut.GetToken(ctx, id)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "first", token)
+	})
+
+	t.Run("Store idempotent", func(t *testing.T) {
+		sut := s.New(t)
+		userId := uuid.NewString()
+		first, second := uuid.NewString(), uuid.NewString()
+		idem := models.Idempotency{
+			Key:         "key",
+			RequestHash: "hash",
+			SessionId:   first,
+			Response:    "sealed",
+			ExpiresAt:   time.Now().Add(time.Minute),
+		}
+
+		existing, stored, err := sut.StoreTokenIdempotent(ctx, first, userId, "first", now, idem)
+
+		require.NoError(t, err)
+		assert.True(t, stored)
+		assert.Equal(t, idem, existing)
+
+		existing, stored, err = sut.StoreTokenIdempotent(ctx, second, userId, "second", now, models.Idempotency{
+			Key:         "key",
+			RequestHash: "other",
+			SessionId:   second,
+			Response:    "other",
+			ExpiresAt:   time.Now().Add(time.Minute),
+		})
+
+		require.NoError(t, err)
+		assert.False(t, stored)
+		assert.Equal(t, "hash", existing.RequestHash)
+		assert.Equal(t, first, existing.SessionId)
+		assert.Equal(t, "sealed", existing.Response)
+
+		_, err = sut.GetToken(ctx, second)
+		assert.ErrorIs(t, err, models.ErrNotValidTokens)
+
+		sessions, err := sut.ListSessions(ctx, userId)
+
+		assert.NoError(t, err)
+		assert.Len(t, sessions, 1)
+
+		got, err := sut.GetIdempotency(ctx, "key")
+
+		require.NoError(t, err)
+		assert.Equal(t, "hash", got.RequestHash)
+		assert.Equal(t, first, got.SessionId)
+		assert.Equal(t, "sealed", got.Response)
+		assert.WithinDuration(t, idem.ExpiresAt, got.ExpiresAt, time.Second)
+	})
+
+	t.Run("Get idempotency unknown", func(t *testing.T) {
+		sut := s.New(t)
+
+		_, err := sut.GetIdempotency(ctx, "unknown")
+
+		assert.ErrorIs(t, err, models.ErrNotValidTokens)
+	})
+
+	t.Run("Store idempotent after expiry", func(t *testing.T) {
+		sut := s.New(t)
+		now := time.Now()
+		first, second := uuid.NewString(), uuid.NewString()
+
+		_, stored, err := sut.StoreTokenIdempotent(ctx, first, uuid.NewString(), "first", now, models.Idempotency{
+			Key:       "key",
+			SessionId: first,
+			ExpiresAt: now.Add(-time.Second),
+		})
+
+		require.NoError(t, err)
+		assert.True(t, stored)
+
+		_, err = sut.GetIdempotency(ctx, "key")
+		assert.ErrorIs(t, err, models.ErrNotValidTokens)
+
+		_, stored, err = sut.StoreTokenIdempotent(ctx, second, uuid.NewString(), "second", now, models.Idempotency{
+			Key:       "key",
+			SessionId: second,
+			ExpiresAt: now.Add(time.Minute),
+		})
+
+		require.NoError(t, err)
+		assert.True(t, stored)
+
+		got, err := sut.GetIdempotency(ctx, "key")
+
+		assert.NoError(t, err)
+		assert.Equal(t, second, got.SessionId)
+	})
+
+	t.Run("Store idempotent concurrently", func(t *testing.T) {
+		const n = 16
+
+		sut := s.New(t)
+		userId := uuid.NewString()
+		sessionIds := make(chan string, n)
+		start := make(chan struct{})
+
+		for range n {
+			go func() {
+				<-start
+
+				id := uuid.NewString()
+
+				existing, _, err := sut.StoreTokenIdempotent(ctx, id, userId, "hashed", now, models.Idempotency{
+					Key:       "key",
+					SessionId: id,
+					ExpiresAt: time.Now().Add(time.Minute),
+				})
+				assert.NoError(t, err)
+
+				sessionIds <- existing.SessionId
+			}()
+		}
+
+		close(start)
+
+		first := <-sessionIds
+
+		for range n - 1 {
+			assert.Equal(t, first, <-sessionIds)
+		}
+
+		sessions, err := sut.ListSessions(ctx, userId)
+
+		assert.NoError(t, err)
+		require.Len(t, sessions, 1)
+		assert.Equal(t, first, sessions[0].Id)
+	})
+
+	t.Run("Destroy", func(t *testing.T) {
+		sut := s.New(t)
+		id := uuid.NewString()
+		userId := uuid.NewString()
+
+		require.NoError(t, sut.StoreToken(ctx, id, userId, "hashed", now))
+		require.NoError(t, sut.DestroyToken(ctx, id))
+
+		_, err := sut.GetToken(ctx, id)
+
+		assert.ErrorIs(t, err, models.ErrNotValidTokens)
+		assert.NoError(t, sut.DestroyToken(ctx, id))
+
+		sessions, err := sut.ListSessions(ctx, userId)
+
+		assert.NoError(t, err)
+		assert.Empty(t, sessions)
+	})
+
+	t.Run("Rotate", func(t *testing.T) {
+		sut := s.New(t)
+		id, newId := uuid.NewString(), uuid.NewString()
+		userId := uuid.NewString()
+
+		require.NoError(t, sut.StoreToken(ctx, id, userId, "old", now))
+		require.NoError(t, sut.RotateToken(ctx, id, "old", newId, userId, "new", now.Add(time.Second), models.Grace{}))
+
+		_, err := sut.GetToken(ctx, id)
+		assert.ErrorIs(t, err, models.ErrNotValidTokens)
+
+		token, err := sut.GetToken(ctx, newId)
+		assert.NoError(t, err)
+		assert.Equal(t, "new", token)
+
+		sessions, err := sut.ListSessions(ctx, userId)
+
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		assert.Equal(t, newId, sessions[0].Id)
+	})
+
+	t.Run("Rotate not valid", func(t *testing.T) {
+		tcs := []struct {
+			key   string
+			id    string
+			token string
+		}{
+			{
+				key:   "Unknown id",
+				id:    uuid.NewString(),
+				token: "old",
+			},
+			{
+				key:   "Other token",
+				token: "other",
+			},
+		}
+
+		for _, tc := range tcs {
+			t.Run(tc.key, func(t *testing.T) {
+				sut := s.New(t)
+				id, newId := uuid.NewString(), uuid.NewString()
+
+				require.NoError(t, sut.StoreToken(ctx, id, uuid.NewString(), "old", now))
+
+				if tc.id == "" {
+					tc.id = id
+				}
+
+				err := sut.RotateToken(ctx, tc.id, tc.token, newId, uuid.NewString(), "new", now, models.Grace{})
+
+				assert.ErrorIs(t, err, models.ErrNotValidTokens)
+
+				_, err = sut.GetToken(ctx, id)
+				assert.NoError(t, err)
+
+				_, err = sut.GetToken(ctx, newId)
+				assert.ErrorIs(t, err, models.ErrNotValidTokens)
+			})
+		}
+	})
+
+	t.Run("Rotate concurrently", func(t *testing.T) {
+		const n = 16
+
+		sut := s.New(t)
+		id := uuid.NewString()
+		userId := uuid.NewString()
+
+		require.NoError(t, sut.StoreToken(ctx, id, userId, "old", now))
+
+		errs := make(chan error, n)
+		start := make(chan struct{})
+
+		for range n {
+			go func() {
+				<-start
+				errs <- sut.RotateToken(ctx, id, "old", uuid.NewString(), userId, "new", now, models.Grace{})
+			}()
+		}
+
+		close(start)
+
+		won := 0
+
+		for range n {
+			err := <-errs
+			if err == nil {
+				won++
+				continue
+			}
+
+			assert.ErrorIs(t, err, models.ErrNotValidTokens)
+		}
+
+		assert.Equal(t, 1, won)
+
+		sessions, err := sut.ListSessions(ctx, userId)
+
+		assert.NoError(t, err)
+		assert.Len(t, sessions, 1)
+	})
+
+	t.Run("Grace", func(t *testing.T) {
+		sut := s.New(t)
+		id := uuid.NewString()
+		userId := uuid.NewString()
+		expiresAt := time.Now().Add(time.Minute)
+
+		require.NoError(t, sut.StoreToken(ctx, id, userId, "old", now))
+
+		_, err := sut.GetGrace(ctx, id)
+		assert.ErrorIs(t, err, models.ErrNotValidTokens)
+
+		require.NoError(t, sut.RotateToken(ctx, id, "old", uuid.NewString(), userId, "new", now, models.Grace{
+			Token:     "old",
+			Successor: "sealed",
+			ExpiresAt: expiresAt,
+		}))
+
+		grace, err := sut.GetGrace(ctx, id)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "old", grace.Token)
+		assert.Equal(t, "sealed", grace.Successor)
+		assert.WithinDuration(t, expiresAt, grace.ExpiresAt, time.Second)
+	})
+
+	t.Run("Grace not stored", func(t *testing.T) {
+		tcs := []struct {
+			key   string
+			grace models.Grace
+		}{
+			{
+				key: "Without grace",
+			},
+			{
+				key: "Expired grace",
+				grace: models.Grace{
+					Token:     "old",
+					Successor: "sealed",
+					ExpiresAt: time.Now().Add(-time.Second),
+				},
+			},
+		}
+
+		for _, tc := range tcs {
+			t.Run(tc.key, func(t *testing.T) {
+				sut := s.New(t)
+				id := uuid.NewString()
+				userId := uuid.NewString()
+
+				require.NoError(t, sut.StoreToken(ctx, id, userId, "old", now))
+				require.NoError(t, sut.RotateToken(ctx, id, "old", uuid.NewString(), userId, "new", now, tc.grace))
+
+				_, err := sut.GetGrace(ctx, id)
+
+				assert.ErrorIs(t, err, models.ErrNotValidTokens)
+			})
+		}
+	})
+
+	t.Run("Grace dropped with successor", func(t *testing.T) {
+		tcs := []struct {
+			key     string
+			destroy func(sut Whitelist, successorId, userId string) error
+		}{
+			{
+				key: "Destroy token",
+				destroy: func(sut Whitelist, successorId, _ string) error {
+					return sut.DestroyToken(ctx, successorId)
+				},
+			},
+			{
+				key: "Destroy user tokens",
+				destroy: func(sut Whitelist, _, userId string) error {
+					_, err := sut.DestroyUserTokens(ctx, userId)
+					return err
+				},
+			},
+			{
+				key: "Destroy tokens before",
+				destroy: func(sut Whitelist, _, _ string) error {
+					_, err := sut.DestroyTokensBefore(ctx, now)
+					return err
+				},
+			},
+			{
+				key: "Rotate successor",
+				destroy: func(sut Whitelist, successorId, userId string) error {
+					return sut.RotateToken(ctx, successorId, "new", uuid.NewString(), userId, "newer", now, models.Grace{})
+				},
+			},
+		}
+
+		for _, tc := range tcs {
+			t.Run(tc.key, func(t *testing.T) {
+				sut := s.New(t)
+				id, successorId := uuid.NewString(), uuid.NewString()
+				userId := uuid.NewString()
+
+				require.NoError(t, sut.StoreToken(ctx, id, userId, "old", now))
+				require.NoError(t, sut.RotateToken(ctx, id, "old", successorId, userId, "new", now, models.Grace{
+					Token:     "old",
+					Successor: "sealed",
+					ExpiresAt: time.Now().Add(time.Minute),
+				}))
+
+				require.NoError(t, tc.destroy(sut, successorId, userId))
+
+				_, err := sut.GetGrace(ctx, id)
+
+				assert.ErrorIs(t, err, models.ErrNotValidTokens)
+			})
+		}
+	})
+
+	t.Run("List sessions", func(t *testing.T) {
+		sut := s.New(t)
+		userId := uuid.NewString()
+		first, second := uuid.NewString(), uuid.NewString()
+
+		require.NoError(t, sut.StoreToken(ctx, second, userId, "hashed", now.Add(time.Second)))
+		require.NoError(t, sut.StoreToken(ctx, first, userId, "hashed", now))
+		require.NoError(t, sut.StoreToken(ctx, uuid.NewString(), uuid.NewString(), "hashed", now))
+
+		sessions, err := sut.ListSessions(ctx, userId)
+
+		require.NoError(t, err)
+		require.Len(t, sessions, 2)
+		assert.Equal(t, first, sessions[0].Id)
+		assert.Equal(t, userId, sessions[0].UserId)
+		assert.True(t, now.Equal(sessions[0].CreatedAt), sessions[0].CreatedAt)
+		assert.Equal(t, second, sessions[1].Id)
+	})
+
+	t.Run("List sessions of unknown user", func(t *testing.T) {
+		sut := s.New(t)
+
+		sessions, err := sut.ListSessions(ctx, uuid.NewString())
+
+		assert.NoError(t, err)
+		assert.Empty(t, sessions)
+	})
+
+	t.Run("Destroy user tokens", func(t *testing.T) {
+		sut := s.New(t)
+		userId := uuid.NewString()
+		other := uuid.NewString()
+
+		require.NoError(t, sut.StoreToken(ctx, other, uuid.NewString(), "hashed", now))
+
+		for range 3 {
+			require.NoError(t, sut.StoreToken(ctx, uuid.NewString(), userId, "hashed", now))
+		}
+
+		n, err := sut.DestroyUserTokens(ctx, userId)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), n)
+
+		sessions, err := sut.ListSessions(ctx, userId)
+
+		assert.NoError(t, err)
+		assert.Empty(t, sessions)
+
+		_, err = sut.GetToken(ctx, other)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("Destroy tokens before", func(t *testing.T) {
+		sut := s.New(t)
+		old, edge, fresh := uuid.NewString(), uuid.NewString(), uuid.NewString()
+
+		require.NoError(t, sut.StoreToken(ctx, old, uuid.NewString(), "hashed", now.Add(-time.Minute)))
+		require.NoError(t, sut.StoreToken(ctx, edge, uuid.NewString(), "hashed", now))
+		require.NoError(t, sut.StoreToken(ctx, fresh, uuid.NewString(), "hashed", now.Add(time.Second)))
+
+		n, err := sut.DestroyTokensBefore(ctx, now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), n)
+
+		_, err = sut.GetToken(ctx, old)
+		assert.ErrorIs(t, err, models.ErrNotValidTokens)
+
+		_, err = sut.GetToken(ctx, edge)
+		assert.ErrorIs(t, err, models.ErrNotValidTokens)
+
+		_, err = sut.GetToken(ctx, fresh)
+		assert.NoError(t, err)
+	})
+
+	if s.Ttl <= 0 {
+		return
+	}
+
+	t.Run("Expired", func(t *testing.T) {
+		sut := s.New(t)
+		id := uuid.NewString()
+		userId := uuid.NewString()
+
+		require.NoError(t, sut.StoreToken(ctx, id, userId, "hashed", time.Now().Add(-2*s.Ttl)))
+
+		_, err := sut.GetToken(ctx, id)
+
+		assert.ErrorIs(t, err, models.ErrNotValidTokens)
+
+		sessions, err := sut.ListSessions(ctx, userId)
+
+		assert.NoError(t, err)
+		assert.Empty(t, sessions)
+
+		n, err := sut.DestroyUserTokens(ctx, userId)
+
+		assert.NoError(t, err)
+		assert.Zero(t, n)
+	})
+
+	t.Run("Not expired", func(t *testing.T) {
+		sut := s.New(t)
+		id := uuid.NewString()
+
+		require.NoError(t, sut.StoreToken(ctx, id, uuid.NewString(), "hashed", time.Now().Add(-s.Ttl/2)))
+
+		_, err := sut.GetToken(ctx, id)
+
+		assert.NoError(t, err)
+	})
+}
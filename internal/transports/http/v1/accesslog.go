@@ -0,0 +1,114 @@
+package httpv1
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/v1adhope/auth-service/pkg/logger"
+)
+
+const _accessLogBodyLimit = 4 << 10
+
+var _accessLogRedactKeys = []string{"accessToken", "refreshToken", "csrfToken"}
+
+type bodyWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *bodyWriter) Write(b []byte) (int, error) {
+	if room := _accessLogBodyLimit - w.body.Len(); room > 0 {
+		w.body.Write(b[:min(len(b), room)])
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// INFO: failed requests are always logged, successful ones by SampleRate
+func accessLog(log Logger, cfg AccessLog) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		var (
+			reqBody []byte
+			w       *bodyWriter
+		)
+
+		if cfg.Bodies {
+			reqBody = peekBody(c.Request)
+			w = &bodyWriter{c.Writer, &bytes.Buffer{}}
+			c.Writer = w
+		}
+
+		c.Next()
+
+		status := c.Writer.Status()
+
+		if status < http.StatusBadRequest && !sampled(cfg.SampleRate) {
+			return
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.Int("bytes", max(c.Writer.Size(), 0)),
+			slog.String("clientIp", clientIp(c)),
+		}
+
+		if cfg.Bodies {
+			attrs = append(attrs,
+				slog.String("reqBody", logger.RedactJSON(reqBody, _accessLogRedactKeys...)),
+				slog.String("respBody", logger.RedactJSON(w.body.Bytes(), _accessLogRedactKeys...)),
+			)
+		}
+
+		log.LogAttrs(c.Request.Context(), accessLogLevel(status), "access", attrs...)
+	}
+}
+
+func peekBody(req *http.Request) []byte {
+	if req.Body == nil {
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(req.Body, _accessLogBodyLimit))
+	if err != nil {
+		return nil
+	}
+
+	req.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+
+	return body
+}
+
+func sampled(rate float64) bool {
+	switch {
+	case rate >= 1:
+		return true
+	case rate <= 0:
+		return false
+	}
+
+	return rand.Float64() < rate
+}
+
+func accessLogLevel(status int) slog.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return slog.LevelError
+	case status >= http.StatusBadRequest:
+		return slog.LevelWarn
+	}
+
+	return slog.LevelInfo
+}
@@ -0,0 +1,15 @@
+package httpv1
+
+import "github.com/gin-gonic/gin"
+
+type adminRouter struct {
+	adminG  *gin.RouterGroup
+	metrics Metrics
+}
+
+// INFO: operational routes, guarded by mtls when enabled
+func initAdminRouter(r *adminRouter) {
+	if r.metrics != nil {
+		r.adminG.GET("/metrics", gin.WrapH(r.metrics.Handler()))
+	}
+}
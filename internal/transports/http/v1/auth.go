@@ -1,6 +1,7 @@
 package httpv1
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -8,8 +9,9 @@ import (
 )
 
 type authRouter struct {
-	apiG *gin.RouterGroup
-	as   AuthService
+	apiG   *gin.RouterGroup
+	as     AuthService
+	cookie RefreshCookie
 }
 
 func initAuthRouter(r *authRouter) {
@@ -32,13 +34,36 @@ func (r *authRouter) tokenPair(c *gin.Context) {
 		return
 	}
 
-	tp, err := r.as.GenerateTokenPair(c.Request.Context(), pathParams.UserId, c.ClientIP())
+	key := c.GetHeader(headerIdempotencyKey)
+	if key == "" {
+		tp, err := r.as.GenerateTokenPair(c.Request.Context(), pathParams.UserId, clientIp(c))
+		if err != nil {
+			setAnyError(c, err)
+			return
+		}
+
+		r.respond(c, tp)
+		return
+	}
+
+	if !isValidIdempotencyKey(key) {
+		setAnyError(c, fmt.Errorf("httpv1: auth: tokenPair: %w", models.ErrNotValidIdempotencyKey))
+		return
+	}
+
+	hash, err := requestHash(c.Request)
+	if err != nil {
+		setBindError(c, err)
+		return
+	}
+
+	tp, err := r.as.GenerateTokenPairIdempotent(c.Request.Context(), key, hash, pathParams.UserId, clientIp(c))
 	if err != nil {
 		setAnyError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, tp)
+	r.respond(c, tp)
 }
 
 type refreshTokenPairReq struct {
@@ -54,16 +79,36 @@ func (r *authRouter) refreshTokenPair(c *gin.Context) {
 		return
 	}
 
+	if r.cookie.Enabled {
+		if refresh, ok := r.cookie.refresh(c); ok {
+			if !isValidCsrf(c) {
+				abortWithErrorMsg(c, http.StatusForbidden, "CSRF token mismatch")
+				return
+			}
+
+			req.Refresh = refresh
+		}
+	}
+
 	tp := models.TokenPair{
 		Access:  req.Access,
 		Refresh: req.Refresh,
 	}
 
-	newTp, err := r.as.RefreshTokenPair(c.Request.Context(), tp, c.ClientIP())
+	newTp, err := r.as.RefreshTokenPair(c.Request.Context(), tp, clientIp(c))
 	if err != nil {
 		setAnyError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, newTp)
+	r.respond(c, newTp)
+}
+
+func (r *authRouter) respond(c *gin.Context, tp models.TokenPair) {
+	if r.cookie.Enabled {
+		r.cookie.respond(c, tp)
+		return
+	}
+
+	c.JSON(http.StatusCreated, tp)
 }
@@ -0,0 +1,35 @@
+package httpv1
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const _ctxKeyClientIp = "clientIp"
+
+// INFO: resolved once per request so handlers, logs and traces agree on the ip
+func resolveClientIp(r ClientIpResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(_ctxKeyClientIp, r.Resolve(c.Request))
+
+		c.Next()
+	}
+}
+
+func clientIp(c *gin.Context) string {
+	return c.GetString(_ctxKeyClientIp)
+}
+
+// INFO: default when no resolver is configured, forwarding headers are never trusted
+type directClientIp struct{}
+
+func (directClientIp) Resolve(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+
+	return host
+}
@@ -0,0 +1,109 @@
+package httpv1
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/v1adhope/auth-service/internal/models"
+)
+
+const (
+	headerCsrfToken = "X-CSRF-Token"
+
+	_refreshCookieName = "refresh_token"
+	_refreshCookiePath = "/v1/tokens/refresh"
+	_csrfCookieName    = "csrf_token"
+	_csrfTokenBytes    = 32
+)
+
+// INFO: browser clients get the refresh token as an HttpOnly cookie instead of
+// the response body, zero values fall back to the defaults
+type RefreshCookie struct {
+	Enabled  bool
+	Name     string
+	Path     string
+	Domain   string
+	SameSite http.SameSite
+	MaxAge   time.Duration
+	Insecure bool
+}
+
+func (rc RefreshCookie) withDefaults() RefreshCookie {
+	if rc.Name == "" {
+		rc.Name = _refreshCookieName
+	}
+
+	if rc.Path == "" {
+		rc.Path = _refreshCookiePath
+	}
+
+	if rc.SameSite == 0 {
+		rc.SameSite = http.SameSiteStrictMode
+	}
+
+	return rc
+}
+
+type cookiePairResp struct {
+	Access string `json:"accessToken"`
+	Csrf   string `json:"csrfToken"`
+}
+
+// INFO: double-submit csrf, the token goes to a cookie readable by the spa
+// and to the body, a cookie refresh has to echo it in headerCsrfToken
+func (rc RefreshCookie) respond(c *gin.Context, tp models.TokenPair) {
+	csrf, err := newCsrfToken()
+	if err != nil {
+		setAnyError(c, err)
+		return
+	}
+
+	c.SetSameSite(rc.SameSite)
+	c.SetCookie(rc.Name, tp.Refresh, rc.maxAge(), rc.Path, rc.Domain, !rc.Insecure, true)
+	c.SetCookie(_csrfCookieName, csrf, rc.maxAge(), "/", rc.Domain, !rc.Insecure, false)
+
+	c.JSON(http.StatusCreated, cookiePairResp{
+		Access: tp.Access,
+		Csrf:   csrf,
+	})
+}
+
+// INFO: session cookie when no lifetime is configured
+func (rc RefreshCookie) maxAge() int {
+	return int(rc.MaxAge.Seconds())
+}
+
+// INFO: reports whether the request carries the refresh cookie,
+// a request without it is served from the body like in json mode
+func (rc RefreshCookie) refresh(c *gin.Context) (string, bool) {
+	refresh, err := c.Cookie(rc.Name)
+	if err != nil || refresh == "" {
+		return "", false
+	}
+
+	return refresh, true
+}
+
+func isValidCsrf(c *gin.Context) bool {
+	cookie, err := c.Cookie(_csrfCookieName)
+	if err != nil || cookie == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(cookie), []byte(c.GetHeader(headerCsrfToken))) == 1
+}
+
+func newCsrfToken() (string, error) {
+	b := make([]byte, _csrfTokenBytes)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("httpv1: cookie: newCsrfToken: Read: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,139 @@
+package httpv1_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	httpv1 "github.com/v1adhope/auth-service/internal/transports/http/v1"
+	"github.com/v1adhope/auth-service/pkg/logger"
+)
+
+func setUpCookie() *gin.Engine {
+	return httpv1.New(newServices(), logger.New()).Handler(
+		httpv1.WithMode(gin.TestMode),
+		httpv1.WithRefreshCookie(httpv1.RefreshCookie{Enabled: true}),
+	)
+}
+
+type cookiePairResp struct {
+	Access  string `json:"accessToken"`
+	Refresh string `json:"refreshToken"`
+	Csrf    string `json:"csrfToken"`
+}
+
+func cookieByName(w *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, c := range w.Result().Cookies() {
+		if c.Name == name {
+			return c
+		}
+	}
+
+	return nil
+}
+
+func TestRefreshCookieIssue(t *testing.T) {
+	handler := setUpCookie()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/tokens/adb21fec-7892-416a-bbfc-9b2d77e8db4a", nil))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	resp := cookiePairResp{}
+
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.NotEmpty(t, resp.Access)
+	assert.NotEmpty(t, resp.Csrf)
+	assert.Empty(t, resp.Refresh)
+
+	refresh := cookieByName(w, "refresh_token")
+	if assert.NotNil(t, refresh) {
+		assert.NotEmpty(t, refresh.Value)
+		assert.True(t, refresh.HttpOnly)
+		assert.True(t, refresh.Secure)
+		assert.Equal(t, http.SameSiteStrictMode, refresh.SameSite)
+		assert.Equal(t, "/v1/tokens/refresh", refresh.Path)
+	}
+
+	csrf := cookieByName(w, "csrf_token")
+	if assert.NotNil(t, csrf) {
+		assert.Equal(t, resp.Csrf, csrf.Value)
+		assert.False(t, csrf.HttpOnly)
+	}
+}
+
+func TestRefreshCookie(t *testing.T) {
+	tcs := []struct {
+		key      string
+		csrf     func(issued string) string
+		noCookie bool
+		expected int
+	}{
+		{
+			key:      "Matching csrf",
+			csrf:     func(issued string) string { return issued },
+			expected: http.StatusCreated,
+		},
+		{
+			key:      "Missing csrf",
+			csrf:     func(string) string { return "" },
+			expected: http.StatusForbidden,
+		},
+		{
+			key:      "Other csrf",
+			csrf:     func(issued string) string { return issued + "x" },
+			expected: http.StatusForbidden,
+		},
+		{
+			key:      "Without cookies",
+			csrf:     func(string) string { return "" },
+			noCookie: true,
+			expected: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.key, func(t *testing.T) {
+			handler := setUpCookie()
+
+			issued := httptest.NewRecorder()
+			handler.ServeHTTP(issued, httptest.NewRequest(http.MethodPost, "/v1/tokens/adb21fec-7892-416a-bbfc-9b2d77e8db4a", nil))
+
+			resp := cookiePairResp{}
+
+			assert.NoError(t, json.Unmarshal(issued.Body.Bytes(), &resp), tc.key)
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/v1/tokens/refresh",
+				strings.NewReader(`{"accessToken":"`+resp.Access+`"}`),
+			)
+			req.Header.Set("X-CSRF-Token", tc.csrf(resp.Csrf))
+
+			if !tc.noCookie {
+				for _, c := range issued.Result().Cookies() {
+					req.AddCookie(c)
+				}
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expected, w.Code, tc.key)
+
+			if tc.expected != http.StatusCreated {
+				return
+			}
+
+			refresh := cookieByName(w, "refresh_token")
+			if assert.NotNil(t, refresh, tc.key) {
+				assert.NotEqual(t, cookieByName(issued, "refresh_token").Value, refresh.Value, tc.key)
+			}
+		})
+	}
+}
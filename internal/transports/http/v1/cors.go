@@ -0,0 +1,106 @@
+package httpv1
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+const _publicPathPrefix = "/v1/"
+
+// INFO: origins may use a single * for subdomains, e.g. https://*.example.com
+type CorsPolicy struct {
+	AllowOrigins     []string
+	AllowMethods     []string
+	AllowHeaders     []string
+	ExposeHeaders    []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+func (p CorsPolicy) Validate() error {
+	for _, origin := range p.AllowOrigins {
+		// INFO: browsers refuse credentialed responses to any origin
+		if origin == "*" && p.AllowCredentials {
+			return fmt.Errorf("httpv1: cors: Validate: origin * can't be combined with credentials")
+		}
+
+		if strings.Count(origin, "*") > 1 {
+			return fmt.Errorf("httpv1: cors: Validate: origin %q has more than one *", origin)
+		}
+	}
+
+	if err := p.config().Validate(); err != nil {
+		return fmt.Errorf("httpv1: cors: Validate: %w", err)
+	}
+
+	return nil
+}
+
+func (p CorsPolicy) config() cors.Config {
+	cfg := cors.Config{
+		AllowOrigins:     p.AllowOrigins,
+		AllowMethods:     p.AllowMethods,
+		AllowHeaders:     p.AllowHeaders,
+		ExposeHeaders:    p.ExposeHeaders,
+		AllowCredentials: p.AllowCredentials,
+		MaxAge:           p.MaxAge,
+	}
+
+	for _, origin := range p.AllowOrigins {
+		if origin != "*" && strings.Contains(origin, "*") {
+			cfg.AllowWildcard = true
+		}
+	}
+
+	return cfg
+}
+
+type corsHandlers struct {
+	public gin.HandlerFunc
+	admin  gin.HandlerFunc
+}
+
+func newCorsHandlers(public, admin CorsPolicy) (*corsHandlers, error) {
+	for _, p := range []CorsPolicy{public, admin} {
+		if err := p.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &corsHandlers{cors.New(public.config()), cors.New(admin.config())}, nil
+}
+
+// INFO: replaces the policies of the built handler, admin nil shares the public one
+func (r *Router) SetCors(public CorsPolicy, admin *CorsPolicy) error {
+	if admin == nil {
+		admin = &public
+	}
+
+	h, err := newCorsHandlers(public, *admin)
+	if err != nil {
+		return err
+	}
+
+	r.cors.Store(h)
+
+	return nil
+}
+
+// INFO: one middleware for the whole engine so preflights, which match no
+// route, still get the policy of the group they target
+func (r *Router) corsPolicies() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h := r.cors.Load()
+
+		if strings.HasPrefix(c.Request.URL.Path, _publicPathPrefix) {
+			h.public(c)
+			return
+		}
+
+		h.admin(c)
+	}
+}
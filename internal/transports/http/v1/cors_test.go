@@ -0,0 +1,146 @@
+package httpv1_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	httpv1 "github.com/v1adhope/auth-service/internal/transports/http/v1"
+	"github.com/v1adhope/auth-service/pkg/logger"
+)
+
+func TestCorsPolicyValidate(t *testing.T) {
+	tcs := []struct {
+		key        string
+		input      httpv1.CorsPolicy
+		expectedOk bool
+	}{
+		{
+			key:        "Any origin",
+			input:      httpv1.CorsPolicy{AllowOrigins: []string{"*"}},
+			expectedOk: true,
+		},
+		{
+			key:   "Any origin with credentials",
+			input: httpv1.CorsPolicy{AllowOrigins: []string{"https://app.example.com", "*"}, AllowCredentials: true},
+		},
+		{
+			key:        "Subdomains with credentials",
+			input:      httpv1.CorsPolicy{AllowOrigins: []string{"https://*.example.com"}, AllowCredentials: true},
+			expectedOk: true,
+		},
+		{
+			key:   "Two wildcards",
+			input: httpv1.CorsPolicy{AllowOrigins: []string{"https://*.*.example.com"}},
+		},
+		{
+			key:   "Without scheme",
+			input: httpv1.CorsPolicy{AllowOrigins: []string{"app.example.com"}},
+		},
+		{
+			key:   "Without origins",
+			input: httpv1.CorsPolicy{},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.key, func(t *testing.T) {
+			err := tc.input.Validate()
+
+			if tc.expectedOk {
+				assert.NoError(t, err, tc.key)
+				return
+			}
+
+			assert.Error(t, err, tc.key)
+		})
+	}
+}
+
+func TestCorsPolicies(t *testing.T) {
+	handler := httpv1.New(nil, logger.New()).Handler(
+		httpv1.WithMode(gin.TestMode),
+		httpv1.WithCors(httpv1.CorsPolicy{
+			AllowOrigins:     []string{"https://*.example.com"},
+			AllowMethods:     []string{"POST"},
+			AllowHeaders:     []string{"X-CSRF-Token"},
+			ExposeHeaders:    []string{"X-Request-Id"},
+			AllowCredentials: true,
+			MaxAge:           10 * time.Minute,
+		}),
+		httpv1.WithAdminCors(httpv1.CorsPolicy{
+			AllowOrigins: []string{"https://admin.internal"},
+			AllowMethods: []string{"GET"},
+		}),
+	)
+
+	tcs := []struct {
+		key                 string
+		path                string
+		origin              string
+		expected            int
+		expectedCredentials string
+		expectedMaxAge      string
+	}{
+		{
+			key:                 "Public subdomain",
+			path:                "/v1/tokens/refresh",
+			origin:              "https://app.example.com",
+			expected:            http.StatusNoContent,
+			expectedCredentials: "true",
+			expectedMaxAge:      "600",
+		},
+		{
+			key:      "Public other origin",
+			path:     "/v1/tokens/refresh",
+			origin:   "https://example.org",
+			expected: http.StatusForbidden,
+		},
+		{
+			key:      "Admin public origin",
+			path:     "/metrics",
+			origin:   "https://app.example.com",
+			expected: http.StatusForbidden,
+		},
+		{
+			key:      "Admin origin",
+			path:     "/metrics",
+			origin:   "https://admin.internal",
+			expected: http.StatusNoContent,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.key, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodOptions, tc.path, nil)
+			req.Header.Set("Origin", tc.origin)
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expected, w.Code, tc.key)
+
+			if tc.expected != http.StatusNoContent {
+				return
+			}
+
+			assert.Equal(t, tc.origin, w.Header().Get("Access-Control-Allow-Origin"), tc.key)
+			assert.Equal(t, tc.expectedCredentials, w.Header().Get("Access-Control-Allow-Credentials"), tc.key)
+			assert.Equal(t, tc.expectedMaxAge, w.Header().Get("Access-Control-Max-Age"), tc.key)
+		})
+	}
+}
+
+func TestCorsPoliciesPanics(t *testing.T) {
+	assert.Panics(t, func() {
+		httpv1.New(nil, logger.New()).Handler(
+			httpv1.WithMode(gin.TestMode),
+			httpv1.WithAllowOrigins([]string{"*"}),
+			httpv1.WithAllowCredentials(true),
+		)
+	})
+}
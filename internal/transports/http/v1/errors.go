@@ -31,20 +31,32 @@ func errorsHandler(log Logger) gin.HandlerFunc {
 
 			switch errType {
 			case gin.ErrorTypeBind:
-				log.Debug(ginErr, "%s", "StatusUnprocessableEntity")
+				log.Debug(c.Request.Context(), ginErr, "%s", "StatusUnprocessableEntity")
 				c.Status(http.StatusUnprocessableEntity)
 				return
 			case gin.ErrorTypeAny:
 				switch {
 				case errors.Is(err, models.ErrNotValidTokens),
-					errors.Is(err, models.ErrNotValidGuid):
-					log.Debug(ginErr, "%s", "StatusBadRequest")
+					errors.Is(err, models.ErrNotValidGuid),
+					errors.Is(err, models.ErrNotValidIdempotencyKey):
+					log.Debug(c.Request.Context(), ginErr, "%s", "StatusBadRequest")
 					abortWithErrorMsg(c, http.StatusBadRequest, err.Error())
 					return
+				// INFO: unknown users get the same answer as disabled ones,
+				// so the endpoint can't be used to enumerate accounts
+				case errors.Is(err, models.ErrUserNotFound),
+					errors.Is(err, models.ErrUserNotActive):
+					log.Debug(c.Request.Context(), ginErr, "%s", "StatusForbidden")
+					abortWithErrorMsg(c, http.StatusForbidden, models.ErrUserNotActive.Error())
+					return
+				case errors.Is(err, models.ErrIdempotencyMismatch):
+					log.Debug(c.Request.Context(), ginErr, "%s", "StatusUnprocessableEntity")
+					abortWithErrorMsg(c, http.StatusUnprocessableEntity, err.Error())
+					return
 				}
 			}
 
-			log.Error(ginErr, "%s", "StatusTeapot")
+			log.Error(c.Request.Context(), ginErr, "%s", "StatusTeapot")
 			c.AbortWithStatus(http.StatusTeapot)
 			return
 		}
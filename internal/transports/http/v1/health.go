@@ -0,0 +1,65 @@
+package httpv1
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const _probeTimeout = 2 * time.Second
+
+type Probe struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+type healthRouter struct {
+	probes   []Probe
+	draining *atomic.Bool
+}
+
+func initHealthRouter(e *gin.Engine, r *healthRouter) {
+	e.GET("/healthz", r.liveness)
+	e.HEAD("/healthz", r.liveness)
+	e.GET("/readyz", r.readiness)
+	e.HEAD("/readyz", r.readiness)
+}
+
+func (r *healthRouter) liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+	})
+}
+
+func (r *healthRouter) readiness(c *gin.Context) {
+	if r.draining.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": "draining",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), _probeTimeout)
+	defer cancel()
+
+	code, status := http.StatusOK, "ok"
+	checks := make(map[string]string, len(r.probes))
+
+	for _, p := range r.probes {
+		if err := p.Check(ctx); err != nil {
+			code, status = http.StatusServiceUnavailable, "fail"
+			checks[p.Name] = err.Error()
+			continue
+		}
+
+		checks[p.Name] = "ok"
+	}
+
+	c.JSON(code, gin.H{
+		"status": status,
+		"checks": checks,
+	})
+}
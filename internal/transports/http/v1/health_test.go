@@ -0,0 +1,107 @@
+package httpv1_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	httpv1 "github.com/v1adhope/auth-service/internal/transports/http/v1"
+)
+
+type testHealthResp struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+func TestHealth(t *testing.T) {
+	okProbe := func(ctx context.Context) error { return nil }
+	failProbe := func(ctx context.Context) error { return errors.New("some err") }
+
+	tcs := []struct {
+		key          string
+		path         string
+		probes       []httpv1.Option
+		drain        bool
+		expected     int
+		expectedResp testHealthResp
+	}{
+		{
+			key:          "Liveness",
+			path:         "/healthz",
+			expected:     http.StatusOK,
+			expectedResp: testHealthResp{Status: "ok"},
+		},
+		{
+			key:  "Liveness while draining",
+			path: "/healthz",
+			probes: []httpv1.Option{
+				httpv1.WithReadinessProbe("postgres", failProbe),
+			},
+			drain:        true,
+			expected:     http.StatusOK,
+			expectedResp: testHealthResp{Status: "ok"},
+		},
+		{
+			key:  "Ready",
+			path: "/readyz",
+			probes: []httpv1.Option{
+				httpv1.WithReadinessProbe("postgres", okProbe),
+				httpv1.WithReadinessProbe("keys", okProbe),
+			},
+			expected: http.StatusOK,
+			expectedResp: testHealthResp{
+				Status: "ok",
+				Checks: map[string]string{"postgres": "ok", "keys": "ok"},
+			},
+		},
+		{
+			key:  "Not ready",
+			path: "/readyz",
+			probes: []httpv1.Option{
+				httpv1.WithReadinessProbe("postgres", failProbe),
+				httpv1.WithReadinessProbe("keys", okProbe),
+			},
+			expected: http.StatusServiceUnavailable,
+			expectedResp: testHealthResp{
+				Status: "fail",
+				Checks: map[string]string{"postgres": "some err", "keys": "ok"},
+			},
+		},
+		{
+			key:  "Draining",
+			path: "/readyz",
+			probes: []httpv1.Option{
+				httpv1.WithReadinessProbe("postgres", okProbe),
+			},
+			drain:        true,
+			expected:     http.StatusServiceUnavailable,
+			expectedResp: testHealthResp{Status: "draining"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run("", func(t *testing.T) {
+			router, handler := setUpWithoutDb(tc.probes...)
+			if tc.drain {
+				router.Drain()
+			}
+
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", tc.path, nil)
+			handler.ServeHTTP(w, req)
+
+			assert.NoError(t, err, tc.key)
+			assert.Equal(t, tc.expected, w.Code, tc.key)
+
+			sut := testHealthResp{}
+			err = json.Unmarshal(w.Body.Bytes(), &sut)
+
+			assert.NoError(t, err, tc.key)
+			assert.Equal(t, tc.expectedResp, sut, tc.key)
+		})
+	}
+}
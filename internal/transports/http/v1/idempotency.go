@@ -0,0 +1,35 @@
+package httpv1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const (
+	headerIdempotencyKey = "Idempotency-Key"
+
+	_idempotencyKeyMaxLen    = 255
+	_idempotencyBodyMaxBytes = 64 << 10
+)
+
+func isValidIdempotencyKey(key string) bool {
+	return isPrintableAscii(key, _idempotencyKeyMaxLen)
+}
+
+// INFO: fingerprint of what a key was first used for, a retry has to match it
+func requestHash(req *http.Request) (string, error) {
+	h := sha256.New()
+
+	fmt.Fprintf(h, "%s\n%s\n", req.Method, req.URL.Path)
+
+	if req.Body != nil {
+		if _, err := io.Copy(h, http.MaxBytesReader(nil, req.Body, _idempotencyBodyMaxBytes)); err != nil {
+			return "", fmt.Errorf("httpv1: idempotency: requestHash: Copy: %w", err)
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,105 @@
+package httpv1_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/v1adhope/auth-service/internal/services"
+	httpv1 "github.com/v1adhope/auth-service/internal/transports/http/v1"
+	"github.com/v1adhope/auth-service/pkg/logger"
+)
+
+func setUpIdempotent() *gin.Engine {
+	return httpv1.New(
+		newServices(services.WithIdempotencyTtl(time.Minute)),
+		logger.New(),
+	).Handler(httpv1.WithMode(gin.TestMode))
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	type call struct {
+		userId string
+		key    string
+	}
+
+	tcs := []struct {
+		key          string
+		first        call
+		second       call
+		expected     int
+		expectedSame bool
+	}{
+		{
+			key:          "Retry",
+			first:        call{"adb21fec-7892-416a-bbfc-9b2d77e8db4a", "key"},
+			second:       call{"adb21fec-7892-416a-bbfc-9b2d77e8db4a", "key"},
+			expected:     http.StatusCreated,
+			expectedSame: true,
+		},
+		{
+			key:      "Other keys",
+			first:    call{"adb21fec-7892-416a-bbfc-9b2d77e8db4a", "key"},
+			second:   call{"adb21fec-7892-416a-bbfc-9b2d77e8db4a", "other"},
+			expected: http.StatusCreated,
+		},
+		{
+			key:      "Without key",
+			first:    call{"adb21fec-7892-416a-bbfc-9b2d77e8db4a", ""},
+			second:   call{"adb21fec-7892-416a-bbfc-9b2d77e8db4a", ""},
+			expected: http.StatusCreated,
+		},
+		{
+			key:      "Same key from another user",
+			first:    call{"adb21fec-7892-416a-bbfc-9b2d77e8db4a", "key"},
+			second:   call{"0f58a78d-0b7c-4e1a-9cc3-6a3c1b9e8d22", "key"},
+			expected: http.StatusCreated,
+		},
+		{
+			key:      "Not valid key",
+			first:    call{"adb21fec-7892-416a-bbfc-9b2d77e8db4a", "key"},
+			second:   call{"adb21fec-7892-416a-bbfc-9b2d77e8db4a", strings.Repeat("k", 256)},
+			expected: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.key, func(t *testing.T) {
+			handler := setUpIdempotent()
+
+			do := func(c call) *httptest.ResponseRecorder {
+				w := httptest.NewRecorder()
+				req := httptest.NewRequest(http.MethodPost, "/v1/tokens/"+c.userId, nil)
+
+				if c.key != "" {
+					req.Header.Set("Idempotency-Key", c.key)
+				}
+
+				handler.ServeHTTP(w, req)
+
+				return w
+			}
+
+			first := do(tc.first)
+			second := do(tc.second)
+
+			assert.Equal(t, http.StatusCreated, first.Code, tc.key)
+			assert.Equal(t, tc.expected, second.Code, tc.key)
+
+			if tc.expected != http.StatusCreated {
+				return
+			}
+
+			if tc.expectedSame {
+				assert.Equal(t, first.Body.String(), second.Body.String(), tc.key)
+				return
+			}
+
+			assert.NotEqual(t, first.Body.String(), second.Body.String(), tc.key)
+		})
+	}
+}
@@ -2,17 +2,31 @@ package httpv1
 
 import (
 	"context"
+	"log/slog"
+	"net/http"
+	"time"
 
 	"github.com/v1adhope/auth-service/internal/models"
 )
 
 type AuthService interface {
 	GenerateTokenPair(ctx context.Context, userId string, ip string) (models.TokenPair, error)
+	GenerateTokenPairIdempotent(ctx context.Context, key, requestHash, userId, ip string) (models.TokenPair, error)
 	RefreshTokenPair(ctx context.Context, tp models.TokenPair, ip string) (models.TokenPair, error)
 }
 
 type Logger interface {
-	Info(format string, msg ...any)
-	Debug(err error, format string, msg ...any)
-	Error(err error, format string, msg ...any)
+	Info(ctx context.Context, format string, msg ...any)
+	Debug(ctx context.Context, err error, format string, msg ...any)
+	Error(ctx context.Context, err error, format string, msg ...any)
+	LogAttrs(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr)
+}
+
+type Metrics interface {
+	ObserveRequest(method, route string, status int, d time.Duration)
+	Handler() http.Handler
+}
+
+type ClientIpResolver interface {
+	Resolve(req *http.Request) string
 }
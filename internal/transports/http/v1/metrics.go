@@ -0,0 +1,25 @@
+package httpv1
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// INFO: unmatched routes share one label to keep cardinality bounded
+const _unmatchedRoute = "unmatched"
+
+func metrics(m Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = _unmatchedRoute
+		}
+
+		m.ObserveRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
@@ -0,0 +1,28 @@
+package httpv1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const _ctxKeyClientCertSubject = "clientCertSubject"
+
+// INFO: the tls layer verifies presented certs against the client ca,
+// this rejects requests that came without one
+func requireClientCert() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.TLS == nil || len(c.Request.TLS.VerifiedChains) == 0 {
+			abortWithErrorMsg(c, http.StatusForbidden, "Client certificate required")
+			return
+		}
+
+		c.Set(_ctxKeyClientCertSubject, c.Request.TLS.VerifiedChains[0][0].Subject.String())
+
+		c.Next()
+	}
+}
+
+func ClientCertSubject(c *gin.Context) string {
+	return c.GetString(_ctxKeyClientCertSubject)
+}
@@ -0,0 +1,79 @@
+package httpv1_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/metrics"
+	httpv1 "github.com/v1adhope/auth-service/internal/transports/http/v1"
+)
+
+func TestAdminMtls(t *testing.T) {
+	verified := &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{
+			{Subject: pkix.Name{CommonName: "ops"}},
+		}},
+	}
+
+	tcs := []struct {
+		key      string
+		mtls     bool
+		path     string
+		input    *tls.ConnectionState
+		expected int
+	}{
+		{
+			key:      "Disabled",
+			path:     "/metrics",
+			expected: http.StatusOK,
+		},
+		{
+			key:      "Verified client cert",
+			mtls:     true,
+			path:     "/metrics",
+			input:    verified,
+			expected: http.StatusOK,
+		},
+		{
+			key:      "Tls without client cert",
+			mtls:     true,
+			path:     "/metrics",
+			input:    &tls.ConnectionState{},
+			expected: http.StatusForbidden,
+		},
+		{
+			key:      "Plain http",
+			mtls:     true,
+			path:     "/metrics",
+			expected: http.StatusForbidden,
+		},
+		{
+			key:      "Public routes aren't guarded",
+			mtls:     true,
+			path:     "/healthz",
+			expected: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run("", func(t *testing.T) {
+			_, handler := setUpWithoutDb(
+				httpv1.WithMetrics(metrics.New()),
+				httpv1.WithAdminMtls(tc.mtls),
+			)
+
+			sut := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", tc.path, nil)
+			req.TLS = tc.input
+			handler.ServeHTTP(sut, req)
+
+			assert.NoError(t, err, tc.key)
+			assert.Equal(t, tc.expected, sut.Code, tc.key)
+		})
+	}
+}
@@ -1,15 +1,35 @@
 package httpv1
 
 import (
-	"github.com/gin-contrib/cors"
+	"context"
+	"time"
+
 	"github.com/gin-gonic/gin"
 )
 
 type Option func(*Config)
 
 type Config struct {
-	Cors cors.Config
-	Mode string
+	Cors            CorsPolicy
+	AdminCors       *CorsPolicy
+	Mode            string
+	AccessLog       AccessLog
+	Metrics         Metrics
+	ReadinessProbes []Probe
+	AdminMtls       bool
+	ClientIp        ClientIpResolver
+	RefreshCookie   RefreshCookie
+}
+
+type AccessLog struct {
+	SampleRate float64
+	Bodies     bool
+}
+
+func WithCors(p CorsPolicy) Option {
+	return func(cfg *Config) {
+		cfg.Cors = p
+	}
 }
 
 func WithAllowOrigins(ao []string) Option {
@@ -30,20 +50,91 @@ func WithAllowHeaders(ah []string) Option {
 	}
 }
 
+func WithAllowCredentials(ac bool) Option {
+	return func(cfg *Config) {
+		cfg.Cors.AllowCredentials = ac
+	}
+}
+
+func WithExposeHeaders(eh []string) Option {
+	return func(cfg *Config) {
+		cfg.Cors.ExposeHeaders = eh
+	}
+}
+
+func WithCorsMaxAge(ma time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.Cors.MaxAge = ma
+	}
+}
+
+// INFO: policy for everything outside /v1, the public one is used when not set
+func WithAdminCors(p CorsPolicy) Option {
+	return func(cfg *Config) {
+		cfg.AdminCors = &p
+	}
+}
+
 func WithMode(m string) Option {
 	return func(cfg *Config) {
 		cfg.Mode = m
 	}
 }
 
+func WithAccessLogSampleRate(sr float64) Option {
+	return func(cfg *Config) {
+		cfg.AccessLog.SampleRate = sr
+	}
+}
+
+func WithAccessLogBodies(b bool) Option {
+	return func(cfg *Config) {
+		cfg.AccessLog.Bodies = b
+	}
+}
+
+func WithMetrics(m Metrics) Option {
+	return func(cfg *Config) {
+		cfg.Metrics = m
+	}
+}
+
+func WithReadinessProbe(name string, check func(ctx context.Context) error) Option {
+	return func(cfg *Config) {
+		cfg.ReadinessProbes = append(cfg.ReadinessProbes, Probe{name, check})
+	}
+}
+
+func WithAdminMtls(am bool) Option {
+	return func(cfg *Config) {
+		cfg.AdminMtls = am
+	}
+}
+
+func WithClientIpResolver(r ClientIpResolver) Option {
+	return func(cfg *Config) {
+		cfg.ClientIp = r
+	}
+}
+
+func WithRefreshCookie(rc RefreshCookie) Option {
+	return func(cfg *Config) {
+		cfg.RefreshCookie = rc
+	}
+}
+
 func config(opts ...Option) Config {
 	cfg := Config{
-		Cors: cors.Config{
+		Cors: CorsPolicy{
 			AllowOrigins: []string{"*"},
 			AllowMethods: []string{"POST", "HEAD", "OPTIONS"},
 			AllowHeaders: []string{"Origin"},
 		},
 		Mode: gin.DebugMode,
+		AccessLog: AccessLog{
+			SampleRate: 1,
+		},
+		ClientIp: directClientIp{},
 	}
 
 	for _, opt := range opts {
@@ -0,0 +1,46 @@
+package httpv1
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/v1adhope/auth-service/pkg/logger"
+)
+
+const (
+	headerRequestId = "X-Request-Id"
+
+	_requestIdMaxLen = 128
+)
+
+func requestId() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(headerRequestId)
+		if !isValidRequestId(id) {
+			id = uuid.NewString()
+		}
+
+		c.Request = c.Request.WithContext(logger.NewContext(c.Request.Context(), id))
+		c.Header(headerRequestId, id)
+
+		c.Next()
+	}
+}
+
+// INFO: incoming ids end up in logs, so only short printable ascii is accepted
+func isValidRequestId(id string) bool {
+	return isPrintableAscii(id, _requestIdMaxLen)
+}
+
+func isPrintableAscii(s string, maxLen int) bool {
+	if s == "" || len(s) > maxLen {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x21 || s[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
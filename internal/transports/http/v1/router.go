@@ -1,14 +1,17 @@
 package httpv1
 
 import (
-	"github.com/gin-contrib/cors"
+	"sync/atomic"
+
 	"github.com/gin-gonic/gin"
 	"github.com/v1adhope/auth-service/internal/services"
 )
 
 type Router struct {
-	as  *services.Services
-	log Logger
+	as       *services.Services
+	log      Logger
+	draining atomic.Bool
+	cors     atomic.Pointer[corsHandlers]
 }
 
 func New(
@@ -21,23 +24,57 @@ func New(
 	}
 }
 
+// INFO: fails readiness so load balancers stop routing before the server shuts down
+func (r *Router) Drain() {
+	r.draining.Store(true)
+}
+
+// INFO: panics on cors policies that don't pass CorsPolicy.Validate
 func (r *Router) Handler(opts ...Option) *gin.Engine {
 	cfg := config(opts...)
 
+	if err := r.SetCors(cfg.Cors, cfg.AdminCors); err != nil {
+		panic(err)
+	}
+
 	gin.SetMode(cfg.Mode)
 
 	e := gin.New()
 
+	// INFO: client ip comes from resolveClientIp, gin must not trust forwarding headers on its own
+	e.ForwardedByClientIP = false
+
+	e.Use(
+		requestId(),
+		resolveClientIp(cfg.ClientIp),
+		tracing(),
+		accessLog(r.log, cfg.AccessLog),
+	)
+
+	// INFO: must wrap errorsHandler to observe the final status
+	if cfg.Metrics != nil {
+		e.Use(metrics(cfg.Metrics))
+	}
+
 	e.Use(
-		gin.Logger(),
 		gin.Recovery(),
-		cors.New(cfg.Cors),
+		r.corsPolicies(),
 		errorsHandler(r.log),
 	)
 
+	initHealthRouter(e, &healthRouter{cfg.ReadinessProbes, &r.draining})
+
+	adminG := e.Group("/")
+	if cfg.AdminMtls {
+		adminG.Use(requireClientCert())
+	}
+	{
+		initAdminRouter(&adminRouter{adminG, cfg.Metrics})
+	}
+
 	apiG := e.Group("/v1")
 	{
-		initAuthRouter(&authRouter{apiG, r.as})
+		initAuthRouter(&authRouter{apiG, r.as, cfg.RefreshCookie.withDefaults()})
 	}
 
 	return e
@@ -0,0 +1,68 @@
+package httpv1_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/v1adhope/auth-service/internal/testhelpers"
+)
+
+func TestTracingTraceparent(t *testing.T) {
+	recorder := testhelpers.RecordSpans()
+	_, handler := setUpWithoutDb()
+
+	tcs := []struct {
+		key         string
+		traceparent string
+		traceId     string
+		parentId    string
+	}{
+		{
+			key:         "Continued",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			traceId:     "4bf92f3577b34da6a3ce929d0e0e4736",
+			parentId:    "00f067aa0ba902b7",
+		},
+		{
+			key:         "New trace",
+			traceparent: "",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run("", func(t *testing.T) {
+			recorder.Reset()
+
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest(
+				"POST",
+				"/v1/tokens/adb21fec-7892-416a-bbfc-9b2d77e8db4a",
+				nil,
+			)
+			req.Header.Set("traceparent", tc.traceparent)
+			handler.ServeHTTP(w, req)
+
+			assert.NoError(t, err, tc.key)
+			assert.Equal(t, http.StatusCreated, w.Code, tc.key)
+
+			spans := recorder.GetSpans()
+			sut := spans[len(spans)-1]
+
+			assert.Equal(t, "POST /v1/tokens/:userId", sut.Name, tc.key)
+
+			for _, span := range spans {
+				assert.Equal(t, sut.SpanContext.TraceID(), span.SpanContext.TraceID(), span.Name)
+			}
+
+			if tc.traceId == "" {
+				assert.False(t, sut.Parent.IsValid(), tc.key)
+				return
+			}
+
+			assert.Equal(t, tc.traceId, sut.SpanContext.TraceID().String(), tc.key)
+			assert.Equal(t, tc.parentId, sut.Parent.SpanID().String(), tc.key)
+		})
+	}
+}
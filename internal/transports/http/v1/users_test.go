@@ -0,0 +1,71 @@
+package httpv1_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/v1adhope/auth-service/internal/services"
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/users"
+	httpv1 "github.com/v1adhope/auth-service/internal/transports/http/v1"
+	"github.com/v1adhope/auth-service/pkg/logger"
+)
+
+func TestUserDirectory(t *testing.T) {
+	directory := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/adb21fec-7892-416a-bbfc-9b2d77e8db4a":
+			w.Write([]byte(`{"status":"active"}`))
+		case "/users/0f58a78d-0b7c-4e1a-9cc3-6a3c1b9e8d22":
+			w.Write([]byte(`{"status":"locked"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer directory.Close()
+
+	handler := httpv1.New(
+		newServices(services.WithUserDirectory(users.New(users.WithUrl(directory.URL+"/users")))),
+		logger.New(),
+	).Handler(httpv1.WithMode(gin.TestMode))
+
+	tcs := []struct {
+		key          string
+		userId       string
+		expected     int
+		expectedBody string
+	}{
+		{
+			key:      "Active",
+			userId:   "adb21fec-7892-416a-bbfc-9b2d77e8db4a",
+			expected: http.StatusCreated,
+		},
+		{
+			key:          "Locked",
+			userId:       "0f58a78d-0b7c-4e1a-9cc3-6a3c1b9e8d22",
+			expected:     http.StatusForbidden,
+			expectedBody: `{"errMsg":"User is disabled or locked"}`,
+		},
+		{
+			key:          "Unknown",
+			userId:       "5b0e7c2a-3f4d-4c8e-9a1b-2d6f8e0c4a7b",
+			expected:     http.StatusForbidden,
+			expectedBody: `{"errMsg":"User is disabled or locked"}`,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.key, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/tokens/"+tc.userId, nil))
+
+			assert.Equal(t, tc.expected, w.Code, tc.key)
+
+			if tc.expectedBody != "" {
+				assert.JSONEq(t, tc.expectedBody, w.Body.String(), tc.key)
+			}
+		})
+	}
+}
@@ -17,7 +17,9 @@ import (
 	"github.com/v1adhope/auth-service/internal/services"
 	"github.com/v1adhope/auth-service/internal/services/infrastructure/alert"
 	"github.com/v1adhope/auth-service/internal/services/infrastructure/hash"
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/metrics"
 	"github.com/v1adhope/auth-service/internal/services/infrastructure/repositories"
+	"github.com/v1adhope/auth-service/internal/services/infrastructure/repositories/memory"
 	"github.com/v1adhope/auth-service/internal/services/infrastructure/tokens"
 	"github.com/v1adhope/auth-service/internal/services/infrastructure/validator"
 	"github.com/v1adhope/auth-service/internal/testhelpers"
@@ -27,9 +29,7 @@ import (
 )
 
 const (
-	_pgMigrationsSourceUrl = "file://../../../../db/migrations"
-
-	_tokensAccessKey  = "secret"
+	_tokensAccessKey  = "sOXgK2jwm7otKu2iq8uy8PN47DZ88T4EodChguRAZ5gUyifoHKU0u63BGlKnCQcW"
 	_tokensAccessTtl  = 3 * time.Minute
 	_tokensRefreshkey = "HC2fAkS4Lyfisrt4agCZgRU7eWPpFgbH"
 	_tokensIssuer     = "auth-service-test"
@@ -57,7 +57,7 @@ func (s *Suite) SetupSuite() {
 
 	s.ctx = context.Background()
 
-	pgC, err := testhelpers.BuildContainer(s.ctx, _pgMigrationsSourceUrl)
+	pgC, err := testhelpers.BuildContainer(s.ctx)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -85,21 +85,21 @@ func (s *Suite) SetupSuite() {
 
 	validator := validator.New()
 
-	hash := hash.New()
+	metrics := metrics.New()
 
-	tokenManager := tokens.New(
-		tokens.WithAccessKey(_tokensAccessKey),
-		tokens.WithAccessTtl(_tokensAccessTtl),
-		tokens.WithRefreshKey(_tokensRefreshkey),
-		tokens.WithIssuer(_tokensIssuer),
+	hash := hash.New(
+		hash.WithObserver(metrics),
 	)
 
+	tokenManager := newTokens(tokens.WithIssuer(_tokensIssuer))
+
 	services := services.New(
 		validator,
 		tokenManager,
 		hash,
 		repos,
 		alert,
+		metrics,
 	)
 
 	log := logger.New(
@@ -111,11 +111,45 @@ func (s *Suite) SetupSuite() {
 		httpv1.WithAllowMethods(_handlerAllowMethods),
 		httpv1.WithAllowHeaders(_handlerAllowHeaders),
 		httpv1.WithMode(_handlerMode),
+		httpv1.WithMetrics(metrics),
 	)
 
 	s.handlerV1 = handler
 }
 
+func newTokens(opts ...tokens.Option) *tokens.Tokens {
+	tm, err := tokens.New(append([]tokens.Option{
+		tokens.WithAccessKey(_tokensAccessKey),
+		tokens.WithAccessTtl(_tokensAccessTtl),
+		tokens.WithRefreshKey(_tokensRefreshkey),
+	}, opts...)...)
+	if err != nil {
+		panic(err)
+	}
+
+	return tm
+}
+
+// INFO: services over an in-memory whitelist
+func newServices(opts ...services.Option) *services.Services {
+	return services.New(
+		validator.New(),
+		newTokens(),
+		hash.New(),
+		memory.New(),
+		alert.New(),
+		metrics.New(),
+		opts...,
+	)
+}
+
+// INFO: for tests that don't touch the whitelist storage
+func setUpWithoutDb(opts ...httpv1.Option) (*httpv1.Router, *gin.Engine) {
+	router := httpv1.New(newServices(), logger.New())
+
+	return router, router.Handler(append([]httpv1.Option{httpv1.WithMode(gin.TestMode)}, opts...)...)
+}
+
 func (s *Suite) TearDownSuite() {
 	if err := s.pgC.Terminate(s.ctx); err != nil {
 		log.Fatalf("httpv1_test: v1_test: TearDownSuite: Terminate: %v", err)
@@ -441,6 +475,77 @@ func (s *Suite) TestTokensFromDifferentPairs() {
 	}
 }
 
+func (s *Suite) TestRequestId() {
+	t := s.T()
+	tcs := []struct {
+		key      string
+		input    string
+		expected string
+	}{
+		{
+			key:      "Propagated",
+			input:    "6f1c2a7e-req",
+			expected: "6f1c2a7e-req",
+		},
+		{
+			key:   "Generated",
+			input: "",
+		},
+		{
+			key:   "Not printable",
+			input: "some\nid",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.key, func(t *testing.T) {
+			sut := httptest.NewRecorder()
+			req, err := http.NewRequest(
+				"POST",
+				"/v1/tokens/adb21fec-7892-416a-bbfc-9b2d77e8db4a",
+				nil,
+			)
+			req.Header.Set("X-Request-Id", tc.input)
+			s.handlerV1.ServeHTTP(sut, req)
+
+			assert.NoError(t, err, tc.key)
+			assert.NotEmpty(t, sut.Header().Get("X-Request-Id"), tc.key)
+
+			if tc.expected != "" {
+				assert.Equal(t, tc.expected, sut.Header().Get("X-Request-Id"), tc.key)
+				return
+			}
+
+			assert.NotEqual(t, tc.input, sut.Header().Get("X-Request-Id"), tc.key)
+		})
+	}
+}
+
+func (s *Suite) TestMetrics() {
+	t := s.T()
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(
+		"POST",
+		"/v1/tokens/adb21fec-7892-416a-bbfc-9b2d77e8db4a",
+		nil,
+	)
+	s.handlerV1.ServeHTTP(w, req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	sut := httptest.NewRecorder()
+	req, err = http.NewRequest("GET", "/metrics", nil)
+	s.handlerV1.ServeHTTP(sut, req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, sut.Code)
+	assert.Contains(t, sut.Body.String(), `auth_service_http_requests_total{method="POST",route="/v1/tokens/:userId",status="201"}`)
+	assert.Contains(t, sut.Body.String(), `auth_service_tokens_total{event="issued"}`)
+	assert.Contains(t, sut.Body.String(), `auth_service_hash_duration_seconds_count{op="do"}`)
+}
+
 func TestSuite(t *testing.T) {
 	suite.Run(t, new(Suite))
 }
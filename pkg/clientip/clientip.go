@@ -0,0 +1,140 @@
+package clientip
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// INFO: resolved for peers without an ip, i.e. connected over a unix socket,
+// unless a trusted socket forwards the client in headers
+const UnixPeer = "unix"
+
+type Resolver struct {
+	mode      string
+	trusted   []netip.Prefix
+	trustUnix bool
+}
+
+func New(opts ...Option) (*Resolver, error) {
+	cfg, err := config(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Resolver{cfg.Mode, cfg.TrustedProxies, cfg.TrustUnix}, nil
+}
+
+// INFO: headers are only honoured when the peer is a trusted proxy,
+// the chain is walked right to left and the first untrusted hop is the client
+func (r *Resolver) Resolve(req *http.Request) string {
+	remote, ok := parseHostPort(req.RemoteAddr)
+
+	trusted := ok && r.isTrusted(remote) || !ok && r.trustUnix
+
+	if r.mode == ModeDirect || !trusted {
+		return format(remote)
+	}
+
+	chain := r.chain(req.Header)
+	client := remote
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		hop, ok := parseHop(chain[i])
+		if !ok {
+			break
+		}
+
+		client = hop
+
+		if !r.isTrusted(hop) {
+			break
+		}
+	}
+
+	return format(client)
+}
+
+func (r *Resolver) chain(h http.Header) []string {
+	chain := []string{}
+
+	switch r.mode {
+	case ModeXForwardedFor:
+		for _, v := range h.Values("X-Forwarded-For") {
+			for _, hop := range strings.Split(v, ",") {
+				chain = append(chain, strings.TrimSpace(hop))
+			}
+		}
+	case ModeForwarded:
+		for _, v := range h.Values("Forwarded") {
+			chain = append(chain, forwardedFor(v)...)
+		}
+	}
+
+	return chain
+}
+
+func (r *Resolver) isTrusted(addr netip.Addr) bool {
+	for _, p := range r.trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// INFO: RFC 7239, elements are comma separated, pairs within one are semicolon separated
+func forwardedFor(v string) []string {
+	hops := []string{}
+
+	for _, element := range strings.Split(v, ",") {
+		for _, pair := range strings.Split(element, ";") {
+			k, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok || !strings.EqualFold(k, "for") {
+				continue
+			}
+
+			hops = append(hops, strings.Trim(val, `"`))
+		}
+	}
+
+	return hops
+}
+
+// INFO: obfuscated and "unknown" nodes aren't addresses and stop the walk
+func parseHop(hop string) (netip.Addr, bool) {
+	if addr, err := netip.ParseAddr(hop); err == nil {
+		return normalize(addr), true
+	}
+
+	return parseHostPort(hop)
+}
+
+func parseHostPort(hostport string) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = strings.Trim(hostport, "[]")
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	return normalize(addr), true
+}
+
+// INFO: ::ffff:1.2.3.4 and 1.2.3.4 are the same client, zones are local to the host
+func normalize(addr netip.Addr) netip.Addr {
+	return addr.Unmap().WithZone("")
+}
+
+func format(addr netip.Addr) string {
+	if !addr.IsValid() {
+		return UnixPeer
+	}
+
+	return addr.String()
+}
@@ -0,0 +1,233 @@
+package clientip_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/v1adhope/auth-service/pkg/clientip"
+)
+
+type testResolveInput struct {
+	mode       string
+	remoteAddr string
+	header     http.Header
+}
+
+func TestResolve(t *testing.T) {
+	trusted := []string{"10.0.0.0/8", "fd00::/8", "192.168.1.1", clientip.TrustedUnix}
+
+	tcs := []struct {
+		key      string
+		input    testResolveInput
+		expected string
+	}{
+		{
+			key: "Direct ignores headers",
+			input: testResolveInput{
+				mode:       clientip.ModeDirect,
+				remoteAddr: "10.0.0.1:4000",
+				header:     http.Header{"X-Forwarded-For": {"1.1.1.1"}},
+			},
+			expected: "10.0.0.1",
+		},
+		{
+			key: "Spoofed header from untrusted peer",
+			input: testResolveInput{
+				mode:       clientip.ModeXForwardedFor,
+				remoteAddr: "203.0.113.7:4000",
+				header:     http.Header{"X-Forwarded-For": {"1.1.1.1"}},
+			},
+			expected: "203.0.113.7",
+		},
+		{
+			key: "Trusted proxy",
+			input: testResolveInput{
+				mode:       clientip.ModeXForwardedFor,
+				remoteAddr: "10.0.0.1:4000",
+				header:     http.Header{"X-Forwarded-For": {"1.1.1.1"}},
+			},
+			expected: "1.1.1.1",
+		},
+		{
+			key: "Client prepended spoofed hop",
+			input: testResolveInput{
+				mode:       clientip.ModeXForwardedFor,
+				remoteAddr: "10.0.0.1:4000",
+				header:     http.Header{"X-Forwarded-For": {"6.6.6.6, 203.0.113.7", "10.0.0.2"}},
+			},
+			expected: "203.0.113.7",
+		},
+		{
+			key: "Every hop trusted",
+			input: testResolveInput{
+				mode:       clientip.ModeXForwardedFor,
+				remoteAddr: "10.0.0.1:4000",
+				header:     http.Header{"X-Forwarded-For": {"10.0.0.3, 192.168.1.1"}},
+			},
+			expected: "10.0.0.3",
+		},
+		{
+			key: "Forwarded",
+			input: testResolveInput{
+				mode:       clientip.ModeForwarded,
+				remoteAddr: "[fd00::1]:4000",
+				header:     http.Header{"Forwarded": {`for="[2001:db8:cafe::17]:4711";proto=https, for=10.0.0.5`}},
+			},
+			expected: "2001:db8:cafe::17",
+		},
+		{
+			key: "Forwarded obfuscated node",
+			input: testResolveInput{
+				mode:       clientip.ModeForwarded,
+				remoteAddr: "10.0.0.1:4000",
+				header:     http.Header{"Forwarded": {"for=_hidden, for=10.0.0.5"}},
+			},
+			expected: "10.0.0.5",
+		},
+		{
+			key: "Ipv4 mapped ipv6",
+			input: testResolveInput{
+				mode:       clientip.ModeDirect,
+				remoteAddr: "[::ffff:198.51.100.4]:4000",
+			},
+			expected: "198.51.100.4",
+		},
+		{
+			key: "Zone",
+			input: testResolveInput{
+				mode:       clientip.ModeDirect,
+				remoteAddr: "[fe80::1%eth0]:4000",
+			},
+			expected: "fe80::1",
+		},
+		{
+			key: "Mapped trusted proxy",
+			input: testResolveInput{
+				mode:       clientip.ModeXForwardedFor,
+				remoteAddr: "[::ffff:10.0.0.1]:4000",
+				header:     http.Header{"X-Forwarded-For": {"::ffff:1.1.1.1"}},
+			},
+			expected: "1.1.1.1",
+		},
+		{
+			key: "Unix socket peer",
+			input: testResolveInput{
+				mode:       clientip.ModeXForwardedFor,
+				remoteAddr: "@",
+				header:     http.Header{"X-Forwarded-For": {"1.1.1.1"}},
+			},
+			expected: "1.1.1.1",
+		},
+		{
+			key: "Direct unix socket peer",
+			input: testResolveInput{
+				mode:       clientip.ModeDirect,
+				remoteAddr: "@",
+				header:     http.Header{"X-Forwarded-For": {"1.1.1.1"}},
+			},
+			expected: clientip.UnixPeer,
+		},
+		{
+			key: "Unix socket peer without headers",
+			input: testResolveInput{
+				mode:       clientip.ModeXForwardedFor,
+				remoteAddr: "",
+			},
+			expected: clientip.UnixPeer,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run("", func(t *testing.T) {
+			r, err := clientip.New(
+				clientip.WithMode(tc.input.mode),
+				clientip.WithTrustedProxies(trusted),
+			)
+
+			assert.NoError(t, err, tc.key)
+
+			req := &http.Request{
+				RemoteAddr: tc.input.remoteAddr,
+				Header:     tc.input.header,
+			}
+
+			sut := r.Resolve(req)
+
+			assert.Equal(t, tc.expected, sut, tc.key)
+		})
+	}
+}
+
+func TestNewNegative(t *testing.T) {
+	tcs := []struct {
+		key   string
+		input []clientip.Option
+	}{
+		{
+			key:   "Unknown mode",
+			input: []clientip.Option{clientip.WithMode("x-real-ip")},
+		},
+		{
+			key:   "Not valid proxy",
+			input: []clientip.Option{clientip.WithTrustedProxies([]string{"10.0.0.0/33"})},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run("", func(t *testing.T) {
+			_, sut := clientip.New(tc.input...)
+
+			assert.Error(t, sut, tc.key)
+		})
+	}
+}
+
+func TestMappedTrustedProxies(t *testing.T) {
+	tcs := []struct {
+		key      string
+		trusted  string
+		expected string
+	}{
+		{
+			key:      "Mapped prefix",
+			trusted:  "::ffff:10.0.0.0/104",
+			expected: "1.1.1.1",
+		},
+		{
+			key:      "Mapped prefix with host bits",
+			trusted:  "::ffff:10.1.2.3/104",
+			expected: "1.1.1.1",
+		},
+		{
+			key:      "Mapped address",
+			trusted:  "::ffff:10.0.0.1",
+			expected: "1.1.1.1",
+		},
+		{
+			key:      "Mapped prefix elsewhere",
+			trusted:  "::ffff:192.168.0.0/112",
+			expected: "10.0.0.1",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run("", func(t *testing.T) {
+			r, err := clientip.New(
+				clientip.WithMode(clientip.ModeXForwardedFor),
+				clientip.WithTrustedProxies([]string{tc.trusted}),
+			)
+
+			assert.NoError(t, err, tc.key)
+
+			req := &http.Request{
+				RemoteAddr: "10.0.0.1:4000",
+				Header:     http.Header{"X-Forwarded-For": {"1.1.1.1"}},
+			}
+
+			sut := r.Resolve(req)
+
+			assert.Equal(t, tc.expected, sut, tc.key)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package clientip
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+const (
+	ModeDirect        = "direct"
+	ModeXForwardedFor = "x-forwarded-for"
+	ModeForwarded     = "forwarded"
+
+	// INFO: trusted proxy entry for peers connected over a unix socket
+	TrustedUnix = "unix"
+)
+
+type Option func(*Config) error
+
+type Config struct {
+	Mode           string
+	TrustedProxies []netip.Prefix
+	TrustUnix      bool
+}
+
+func WithMode(m string) Option {
+	return func(cfg *Config) error {
+		switch m {
+		case ModeDirect, ModeXForwardedFor, ModeForwarded:
+			cfg.Mode = m
+			return nil
+		}
+
+		return fmt.Errorf("clientip: opts: WithMode: unknown mode %q", m)
+	}
+}
+
+// INFO: accepts CIDRs, bare addresses and TrustedUnix
+func WithTrustedProxies(proxies []string) Option {
+	return func(cfg *Config) error {
+		for _, p := range proxies {
+			p = strings.TrimSpace(p)
+
+			switch p {
+			case "":
+				continue
+			case TrustedUnix:
+				cfg.TrustUnix = true
+				continue
+			}
+
+			prefix, err := netip.ParsePrefix(p)
+			if err != nil {
+				addr, addrErr := netip.ParseAddr(p)
+				if addrErr != nil {
+					return fmt.Errorf("clientip: opts: WithTrustedProxies: ParsePrefix: %w", err)
+				}
+
+				prefix = netip.PrefixFrom(addr, addr.BitLen())
+			}
+
+			cfg.TrustedProxies = append(cfg.TrustedProxies, unmapPrefix(prefix))
+		}
+
+		return nil
+	}
+}
+
+// INFO: peers are unmapped before matching, so ::ffff:10.0.0.0/104 has to become 10.0.0.0/8,
+// a mapped prefix shorter than /96 covers every ipv4 address
+func unmapPrefix(prefix netip.Prefix) netip.Prefix {
+	addr, bits := prefix.Addr().WithZone(""), prefix.Bits()
+
+	if addr.Is4In6() {
+		addr, bits = addr.Unmap(), max(bits-96, 0)
+	}
+
+	return netip.PrefixFrom(addr, bits).Masked()
+}
+
+func config(opts ...Option) (Config, error) {
+	cfg := Config{
+		Mode: ModeDirect,
+	}
+
+	for _, opt := range opts {
+		if err := opt(&cfg); err != nil {
+			return Config{}, err
+		}
+	}
+
+	return cfg, nil
+}
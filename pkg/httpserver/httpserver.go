@@ -2,17 +2,22 @@ package httpserver
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
 	"time"
 )
 
 type Server struct {
 	s               *http.Server
+	sockets         []string
+	socketMode      os.FileMode
 	shutdownTimeout time.Duration
+	drainDelay      time.Duration
+	onShutdown      []func()
+	tls             Tls
 }
 
 func New(h http.Handler, opts ...Option) *Server {
@@ -21,41 +26,103 @@ func New(h http.Handler, opts ...Option) *Server {
 	return &Server{
 		s: &http.Server{
 			Handler:      h,
-			Addr:         cfg.Socket,
 			WriteTimeout: cfg.WriteTimeout,
 			ReadTimeout:  cfg.ReadTimeout,
 		},
+		sockets:         cfg.Sockets,
+		socketMode:      cfg.SocketMode,
+		shutdownTimeout: cfg.ShutdownTimeout,
+		drainDelay:      cfg.DrainDelay,
+		onShutdown:      cfg.OnShutdown,
+		tls:             cfg.Tls,
 	}
 }
 
-func (s *Server) Run() {
-	go func() {
-		if err := s.s.ListenAndServe(); err != http.ErrServerClosed && err != nil {
-			log.Fatalf("httpserver: listenAndServe: %s", err)
-		}
-	}()
+// INFO: blocks until ctx is done or any listener fails, a clean shutdown returns nil
+func (s *Server) Run(ctx context.Context) error {
+	serve, reloader, err := s.prepare()
+	if err != nil {
+		return err
+	}
+
+	listeners, err := listen(s.sockets, s.socketMode)
+	if err != nil {
+		return err
+	}
+
+	// INFO: the watcher lives as long as Run, whichever way it returns
+	watchCtx, stopWatch := context.WithCancel(ctx)
+	defer stopWatch()
+
+	if reloader != nil && s.tls.ReloadInterval > 0 {
+		go reloader.watch(watchCtx, s.tls.ReloadInterval, s.tls.OnReloadError)
+	}
+
+	serveErr := make(chan error, len(listeners))
 
-	s.gracefulShutdown()
+	for _, l := range listeners {
+		go func() {
+			if err := serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				serveErr <- fmt.Errorf("httpserver: httpserver: Run: serve %s: %w", l.Addr(), err)
+			}
+		}()
+	}
+
+	select {
+	case err := <-serveErr:
+		s.s.Close()
+		return err
+	case <-ctx.Done():
+	}
+
+	return s.gracefulShutdown()
 }
 
-func (s *Server) gracefulShutdown() {
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+func (s *Server) prepare() (func(l net.Listener) error, *certReloader, error) {
+	if s.tls.CertFile == "" || s.tls.KeyFile == "" {
+		return s.s.Serve, nil, nil
+	}
 
-	log.Print("shutdown server ...")
+	reloader, err := newCertReloader(s.tls.CertFile, s.tls.KeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
-	defer cancel()
+	s.s.TLSConfig, err = tlsConfig(reloader, s.tls.ClientCaFile)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	if err := s.s.Shutdown(ctx); err != nil {
-		log.Fatalf("httpserver: gracefulShutdown: shutdown: %s", err)
+	return func(l net.Listener) error {
+		return s.s.ServeTLS(l, "", "")
+	}, reloader, nil
+}
+
+// INFO: the drain delay and the shutdown timeout share one deadline, connections
+// still open once it passes are closed so Run never outlives it
+func (s *Server) gracefulShutdown() error {
+	for _, f := range s.onShutdown {
+		f()
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), s.drainDelay+s.shutdownTimeout)
+	defer cancel()
+
+	drain := time.NewTimer(s.drainDelay)
+	defer drain.Stop()
+
 	select {
+	case <-drain.C:
 	case <-ctx.Done():
-		log.Printf("timeout of %s seconds", s.shutdownTimeout.String())
 	}
 
-	log.Print("server exiting")
+	if err := s.s.Shutdown(ctx); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			s.s.Close()
+		}
+
+		return fmt.Errorf("httpserver: httpserver: gracefulShutdown: Shutdown: %w", err)
+	}
+
+	return nil
 }
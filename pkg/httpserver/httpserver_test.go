@@ -0,0 +1,138 @@
+package httpserver_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/v1adhope/auth-service/pkg/httpserver"
+)
+
+func freeSocket(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	return l.Addr().String()
+}
+
+func TestRunStopsOnContextCancel(t *testing.T) {
+	socket := freeSocket(t)
+	hooked := false
+
+	s := httpserver.New(
+		http.NotFoundHandler(),
+		httpserver.WithSocket(socket),
+		httpserver.WithShutdownTimeout(time.Second),
+		httpserver.WithOnShutdown(func() { hooked = true }),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+
+	go func() {
+		done <- s.Run(ctx)
+	}()
+
+	assert.Eventually(t, func() bool {
+		resp, err := http.Get("http://" + socket)
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+
+		return resp.StatusCode == http.StatusNotFound
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+
+	select {
+	case sut := <-done:
+		assert.NoError(t, sut)
+		assert.True(t, hooked)
+	case <-time.After(3 * time.Second):
+		t.Fatal("server didn't stop")
+	}
+}
+
+func TestRunClosesConnectionsAfterShutdownTimeout(t *testing.T) {
+	socket := freeSocket(t)
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+
+	s := httpserver.New(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+		}),
+		httpserver.WithSocket(socket),
+		httpserver.WithShutdownTimeout(50*time.Millisecond),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+
+	go func() {
+		done <- s.Run(ctx)
+	}()
+
+	reqErr := make(chan error, 1)
+
+	go func() {
+		assert.Eventually(t, func() bool {
+			conn, err := net.Dial("tcp", socket)
+			if err != nil {
+				return false
+			}
+			conn.Close()
+
+			return true
+		}, time.Second, 10*time.Millisecond)
+
+		resp, err := http.Get("http://" + socket)
+		if err == nil {
+			resp.Body.Close()
+		}
+
+		reqErr <- err
+	}()
+
+	<-started
+	cancel()
+
+	select {
+	case sut := <-done:
+		assert.ErrorIs(t, sut, context.DeadlineExceeded)
+	case <-time.After(3 * time.Second):
+		t.Fatal("server didn't stop")
+	}
+
+	select {
+	case err := <-reqErr:
+		assert.Error(t, err)
+	case <-time.After(3 * time.Second):
+		t.Fatal("in flight request wasn't closed")
+	}
+}
+
+func TestRunReturnsListenError(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	s := httpserver.New(
+		http.NotFoundHandler(),
+		httpserver.WithSocket(l.Addr().String()),
+	)
+
+	sut := s.Run(context.Background())
+
+	assert.Error(t, sut)
+}
@@ -0,0 +1,164 @@
+package httpserver
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	_schemeTcp  = "tcp://"
+	_schemeUnix = "unix://"
+	_schemeFd   = "fd://"
+
+	// INFO: sd_listen_fds(3), passed descriptors start right after stdio
+	_listenFdsStart = 3
+
+	_unixProbeTimeout = time.Second
+)
+
+// INFO: socket forms:
+// ":8080", "tcp://127.0.0.1:8080" - tcp;
+// "unix:///run/auth.sock" - unix domain socket created with mode;
+// "fd://" - every systemd passed listener, "fd://3" - by number, "fd://http" - by FileDescriptorName
+func listen(sockets []string, mode os.FileMode) (_ []net.Listener, err error) {
+	listeners := make([]net.Listener, 0, len(sockets))
+	defer func() {
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+		}
+	}()
+
+	var passed []*os.File
+
+	for _, socket := range sockets {
+		switch {
+		case strings.HasPrefix(socket, _schemeUnix):
+			l, err := listenUnix(strings.TrimPrefix(socket, _schemeUnix), mode)
+			if err != nil {
+				return nil, err
+			}
+
+			listeners = append(listeners, l)
+		case strings.HasPrefix(socket, _schemeFd):
+			if passed == nil {
+				if passed, err = listenFds(); err != nil {
+					return nil, err
+				}
+
+				// INFO: selected descriptors are duplicated by their listeners,
+				// the rest would stay open unserved for the process lifetime
+				defer func() {
+					for _, f := range passed {
+						f.Close()
+					}
+				}()
+			}
+
+			ls, err := selectFds(passed, strings.TrimPrefix(socket, _schemeFd))
+			if err != nil {
+				return nil, err
+			}
+
+			listeners = append(listeners, ls...)
+		default:
+			l, err := net.Listen("tcp", strings.TrimPrefix(socket, _schemeTcp))
+			if err != nil {
+				return nil, fmt.Errorf("httpserver: listen: listen: Listen: %w", err)
+			}
+
+			listeners = append(listeners, l)
+		}
+	}
+
+	return listeners, nil
+}
+
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	// INFO: a socket file left by a crashed process blocks bind, it's removed
+	// only once a dial is refused so a running instance keeps its socket
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		conn, err := net.DialTimeout("unix", path, _unixProbeTimeout)
+		if err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("httpserver: listen: listenUnix: %s is in use", path)
+		}
+
+		if !errors.Is(err, syscall.ECONNREFUSED) {
+			return nil, fmt.Errorf("httpserver: listen: listenUnix: Dial: %w", err)
+		}
+
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("httpserver: listen: listenUnix: Remove: %w", err)
+		}
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("httpserver: listen: listenUnix: Listen: %w", err)
+	}
+
+	if err := os.Chmod(path, mode); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("httpserver: listen: listenUnix: Chmod: %w", err)
+	}
+
+	return l, nil
+}
+
+func listenFds() ([]*os.File, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, errors.New("httpserver: listen: listenFds: LISTEN_PID isn't this process")
+	}
+
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n < 1 {
+		return nil, errors.New("httpserver: listen: listenFds: no LISTEN_FDS passed")
+	}
+
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	files := make([]*os.File, 0, n)
+
+	for i := 0; i < n; i++ {
+		name := strconv.Itoa(_listenFdsStart + i)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+
+		files = append(files, os.NewFile(uintptr(_listenFdsStart+i), name))
+	}
+
+	return files, nil
+}
+
+func selectFds(passed []*os.File, selector string) ([]net.Listener, error) {
+	listeners := []net.Listener{}
+
+	for _, f := range passed {
+		if selector != "" && selector != f.Name() && selector != strconv.Itoa(int(f.Fd())) {
+			continue
+		}
+
+		l, err := net.FileListener(f)
+		if err != nil {
+			return nil, fmt.Errorf("httpserver: listen: selectFds: FileListener: %w", err)
+		}
+
+		listeners = append(listeners, l)
+	}
+
+	if len(listeners) == 0 {
+		return nil, fmt.Errorf("httpserver: listen: selectFds: no passed listener matches %q", selector)
+	}
+
+	return listeners, nil
+}
@@ -0,0 +1,205 @@
+package httpserver_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/v1adhope/auth-service/pkg/httpserver"
+)
+
+func unixClient(path string) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", path)
+			},
+		},
+	}
+}
+
+func TestRunMultipleSockets(t *testing.T) {
+	tcpSocket := freeSocket(t)
+	unixPath := filepath.Join(t.TempDir(), "auth.sock")
+
+	runServer(t, http.NotFoundHandler(),
+		httpserver.WithSockets([]string{"tcp://" + tcpSocket, "unix://" + unixPath}),
+		httpserver.WithSocketMode(0o600),
+	)
+
+	tcs := []struct {
+		key    string
+		client *http.Client
+		url    string
+	}{
+		{
+			key:    "Tcp",
+			client: http.DefaultClient,
+			url:    "http://" + tcpSocket,
+		},
+		{
+			key:    "Unix",
+			client: unixClient(unixPath),
+			url:    "http://unix",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run("", func(t *testing.T) {
+			assert.Eventually(t, func() bool {
+				resp, err := tc.client.Get(tc.url)
+				if err != nil {
+					return false
+				}
+				resp.Body.Close()
+
+				return resp.StatusCode == http.StatusNotFound
+			}, time.Second, 10*time.Millisecond, tc.key)
+		})
+	}
+
+	info, err := os.Stat(unixPath)
+
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestRunStaleUnixSocket(t *testing.T) {
+	unixPath := filepath.Join(t.TempDir(), "auth.sock")
+
+	stale, err := net.Listen("unix", unixPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	runServer(t, http.NotFoundHandler(),
+		httpserver.WithSocket("unix://"+unixPath),
+	)
+
+	assert.Eventually(t, func() bool {
+		resp, err := unixClient(unixPath).Get("http://unix")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+
+		return true
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRunLiveUnixSocket(t *testing.T) {
+	unixPath := filepath.Join(t.TempDir(), "auth.sock")
+
+	runServer(t, http.NotFoundHandler(),
+		httpserver.WithSocket("unix://"+unixPath),
+	)
+
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(unixPath)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	sut := httpserver.New(
+		http.NotFoundHandler(),
+		httpserver.WithSockets([]string{"unix://" + unixPath}),
+	).Run(ctx)
+
+	assert.Error(t, sut)
+
+	resp, err := unixClient(unixPath).Get("http://unix")
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+	}
+}
+
+// INFO: systemd passes descriptors from 3 on, so the server runs in a child
+// process started with the listeners as its extra files
+func TestRunPassedFds(t *testing.T) {
+	if os.Getenv("HTTPSERVER_TEST_PASSED_FDS") != "" {
+		t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+		t.Setenv("LISTEN_FDS", "2")
+		t.Setenv("LISTEN_FDNAMES", "http:metrics")
+
+		httpserver.New(
+			http.NotFoundHandler(),
+			httpserver.WithSockets([]string{"fd://http"}),
+		).Run(context.Background())
+
+		return
+	}
+
+	files := make([]*os.File, 0, 2)
+	sockets := make([]string, 0, 2)
+
+	for range 2 {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		f, err := l.(*net.TCPListener).File()
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.Close()
+
+		files = append(files, f)
+		sockets = append(sockets, l.Addr().String())
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestRunPassedFds$")
+	cmd.Env = append(os.Environ(), "HTTPSERVER_TEST_PASSED_FDS=1")
+	cmd.ExtraFiles = files
+
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	for _, f := range files {
+		f.Close()
+	}
+
+	assert.Eventually(t, func() bool {
+		resp, err := http.Get("http://" + sockets[0])
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+
+		return resp.StatusCode == http.StatusNotFound
+	}, 5*time.Second, 10*time.Millisecond)
+
+	_, err := net.Dial("tcp", sockets[1])
+
+	assert.Error(t, err)
+}
+
+func TestRunWithoutPassedFds(t *testing.T) {
+	t.Setenv("LISTEN_PID", "")
+	t.Setenv("LISTEN_FDS", "")
+
+	s := httpserver.New(
+		http.NotFoundHandler(),
+		httpserver.WithSockets([]string{"fd://"}),
+	)
+
+	sut := s.Run(context.Background())
+
+	assert.Error(t, sut)
+}
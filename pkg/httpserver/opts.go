@@ -1,19 +1,47 @@
 package httpserver
 
-import "time"
+import (
+	"os"
+	"time"
+)
 
 type Option func(*Config)
 
 type Config struct {
-	Socket          string
+	Sockets         []string
+	SocketMode      os.FileMode
 	ShutdownTimeout time.Duration
 	WriteTimeout    time.Duration
 	ReadTimeout     time.Duration
+	DrainDelay      time.Duration
+	OnShutdown      []func()
+	Tls             Tls
+}
+
+type Tls struct {
+	CertFile       string
+	KeyFile        string
+	ClientCaFile   string
+	ReloadInterval time.Duration
+	OnReloadError  func(error)
 }
 
 func WithSocket(socket string) Option {
 	return func(cfg *Config) {
-		cfg.Socket = socket
+		cfg.Sockets = []string{socket}
+	}
+}
+
+// INFO: see listen for supported forms
+func WithSockets(sockets []string) Option {
+	return func(cfg *Config) {
+		cfg.Sockets = sockets
+	}
+}
+
+func WithSocketMode(sm os.FileMode) Option {
+	return func(cfg *Config) {
+		cfg.SocketMode = sm
 	}
 }
 
@@ -35,9 +63,55 @@ func WithReadTimeout(rt time.Duration) Option {
 	}
 }
 
+func WithDrainDelay(dd time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.DrainDelay = dd
+	}
+}
+
+// INFO: hooks run as soon as shutdown begins, before the drain delay
+func WithOnShutdown(f func()) Option {
+	return func(cfg *Config) {
+		cfg.OnShutdown = append(cfg.OnShutdown, f)
+	}
+}
+
+// INFO: tls is enabled when both files are set
+func WithTls(certFile, keyFile string) Option {
+	return func(cfg *Config) {
+		cfg.Tls.CertFile = certFile
+		cfg.Tls.KeyFile = keyFile
+	}
+}
+
+func WithClientCa(caFile string) Option {
+	return func(cfg *Config) {
+		cfg.Tls.ClientCaFile = caFile
+	}
+}
+
+// INFO: zero or less disables reloading, the pair is read once at start
+func WithTlsReloadInterval(ri time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.Tls.ReloadInterval = ri
+	}
+}
+
+// INFO: the old key pair keeps being served while reloads fail
+func WithOnTlsReloadError(f func(error)) Option {
+	return func(cfg *Config) {
+		cfg.Tls.OnReloadError = f
+	}
+}
+
 func config(opts ...Option) Config {
 	cfg := Config{
-		Socket: ":8080",
+		Sockets:         []string{":8080"},
+		SocketMode:      0o660,
+		ShutdownTimeout: 5 * time.Second,
+		Tls: Tls{
+			ReloadInterval: 10 * time.Second,
+		},
 	}
 
 	for _, opt := range opts {
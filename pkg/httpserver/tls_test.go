@@ -0,0 +1,291 @@
+package httpserver_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/v1adhope/auth-service/pkg/httpserver"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func issueCert(t *testing.T, cn string, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	signerCert, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	} else {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signerCert, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testCert{cert, key}
+}
+
+func (c *testCert) write(t *testing.T, certFile, keyFile string) {
+	keyDer, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw})
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+
+	if err := os.WriteFile(certFile, certPem, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if keyFile != "" {
+		if err := os.WriteFile(keyFile, keyPem, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func (c *testCert) tls(t *testing.T) tls.Certificate {
+	return tls.Certificate{
+		Certificate: [][]byte{c.cert.Raw},
+		PrivateKey:  c.key,
+	}
+}
+
+func runServer(t *testing.T, h http.Handler, opts ...httpserver.Option) string {
+	socket := freeSocket(t)
+
+	s := httpserver.New(h, append([]httpserver.Option{httpserver.WithSocket(socket)}, opts...)...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+
+	go func() {
+		done <- s.Run(ctx)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	return socket
+}
+
+func servedCert(socket string) (string, error) {
+	conn, err := tls.Dial("tcp", socket, &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	return conn.ConnectionState().PeerCertificates[0].Subject.CommonName, nil
+}
+
+func TestTlsReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+
+	ca := issueCert(t, "ca", nil)
+	issueCert(t, "first", ca).write(t, certFile, keyFile)
+
+	socket := runServer(t, http.NotFoundHandler(),
+		httpserver.WithTls(certFile, keyFile),
+		httpserver.WithTlsReloadInterval(10*time.Millisecond),
+	)
+
+	assert.Eventually(t, func() bool {
+		cn, err := servedCert(socket)
+		return err == nil && cn == "first"
+	}, time.Second, 10*time.Millisecond)
+
+	second := issueCert(t, "second", ca)
+	second.write(t, certFile, keyFile)
+
+	// INFO: mtime resolution may be coarse, make the change visible
+	future := time.Now().Add(time.Second)
+	os.Chtimes(certFile, future, future)
+
+	assert.Eventually(t, func() bool {
+		cn, err := servedCert(socket)
+		return err == nil && cn == "second"
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestTlsWithoutReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+
+	ca := issueCert(t, "ca", nil)
+	issueCert(t, "first", ca).write(t, certFile, keyFile)
+
+	socket := runServer(t, http.NotFoundHandler(),
+		httpserver.WithTls(certFile, keyFile),
+		httpserver.WithTlsReloadInterval(0),
+	)
+
+	assert.Eventually(t, func() bool {
+		cn, err := servedCert(socket)
+		return err == nil && cn == "first"
+	}, time.Second, 10*time.Millisecond)
+
+	issueCert(t, "second", ca).write(t, certFile, keyFile)
+
+	future := time.Now().Add(time.Second)
+	os.Chtimes(certFile, future, future)
+
+	cn, err := servedCert(socket)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "first", cn)
+}
+
+func TestTlsReloadError(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+
+	issueCert(t, "first", issueCert(t, "ca", nil)).write(t, certFile, keyFile)
+
+	reloadErrs := make(chan error, 1)
+
+	socket := runServer(t, http.NotFoundHandler(),
+		httpserver.WithTls(certFile, keyFile),
+		httpserver.WithTlsReloadInterval(10*time.Millisecond),
+		httpserver.WithOnTlsReloadError(func(err error) {
+			select {
+			case reloadErrs <- err:
+			default:
+			}
+		}),
+	)
+
+	assert.Eventually(t, func() bool {
+		cn, err := servedCert(socket)
+		return err == nil && cn == "first"
+	}, time.Second, 10*time.Millisecond)
+
+	if err := os.WriteFile(certFile, []byte("broken"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	future := time.Now().Add(time.Second)
+	os.Chtimes(certFile, future, future)
+
+	select {
+	case err := <-reloadErrs:
+		assert.Error(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("reload error not reported")
+	}
+
+	cn, err := servedCert(socket)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "first", cn)
+}
+
+func TestMutualTls(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.crt")
+
+	ca := issueCert(t, "ca", nil)
+	ca.write(t, caFile, "")
+	issueCert(t, "server", ca).write(t, certFile, keyFile)
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.VerifiedChains) == 0 {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		w.Write([]byte(r.TLS.VerifiedChains[0][0].Subject.CommonName))
+	})
+
+	socket := runServer(t, h,
+		httpserver.WithTls(certFile, keyFile),
+		httpserver.WithClientCa(caFile),
+	)
+
+	tcs := []struct {
+		key      string
+		input    []tls.Certificate
+		expected int
+	}{
+		{
+			key:      "With client cert",
+			input:    []tls.Certificate{issueCert(t, "ops", ca).tls(t)},
+			expected: http.StatusOK,
+		},
+		{
+			key:      "Without client cert",
+			input:    nil,
+			expected: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run("", func(t *testing.T) {
+			client := &http.Client{
+				Transport: &http.Transport{
+					TLSClientConfig: &tls.Config{
+						InsecureSkipVerify: true,
+						Certificates:       tc.input,
+					},
+				},
+			}
+
+			var sut *http.Response
+
+			assert.Eventually(t, func() bool {
+				resp, err := client.Get("https://" + socket)
+				if err != nil {
+					return false
+				}
+
+				sut = resp
+				return true
+			}, time.Second, 10*time.Millisecond, tc.key)
+
+			defer sut.Body.Close()
+
+			assert.Equal(t, tc.expected, sut.StatusCode, tc.key)
+		})
+	}
+}
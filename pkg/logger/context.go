@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+type ctxKey struct{}
+
+// INFO: stored by pointer so ids set deeper in the call chain (services, repositories)
+// are visible to log calls made by outer middlewares after c.Next()
+type fields struct {
+	mu        sync.RWMutex
+	requestId string
+	userId    string
+	sessionId string
+}
+
+func NewContext(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, &fields{requestId: requestId})
+}
+
+func RequestId(ctx context.Context) string {
+	f := fromContext(ctx)
+	if f == nil {
+		return ""
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.requestId
+}
+
+func SetUserId(ctx context.Context, id string) {
+	if f := fromContext(ctx); f != nil {
+		f.mu.Lock()
+		f.userId = id
+		f.mu.Unlock()
+	}
+}
+
+func SetSessionId(ctx context.Context, id string) {
+	if f := fromContext(ctx); f != nil {
+		f.mu.Lock()
+		f.sessionId = id
+		f.mu.Unlock()
+	}
+}
+
+func fromContext(ctx context.Context) *fields {
+	if ctx == nil {
+		return nil
+	}
+
+	f, _ := ctx.Value(ctxKey{}).(*fields)
+
+	return f
+}
+
+func (f *fields) attrs() []slog.Attr {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	attrs := make([]slog.Attr, 0, 3)
+
+	if f.requestId != "" {
+		attrs = append(attrs, slog.String("requestId", f.requestId))
+	}
+
+	if f.userId != "" {
+		attrs = append(attrs, slog.String("userId", f.userId))
+	}
+
+	if f.sessionId != "" {
+		attrs = append(attrs, slog.String("sessionId", f.sessionId))
+	}
+
+	return attrs
+}
@@ -1,9 +1,9 @@
 package logger
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
-	"os"
 )
 
 const (
@@ -14,31 +14,43 @@ const (
 
 type Log struct {
 	*slog.Logger
+	level *slog.LevelVar
 }
 
 func New(opts ...Option) *Log {
 	cfg := config(opts...)
 
-	log := slog.New(slog.NewJSONHandler(os.Stdout, &cfg))
+	level := &slog.LevelVar{}
+	level.Set(cfg.Handler.Level.Level())
+	cfg.Handler.Level = level
+
+	log := slog.New(ctxHandler{slog.NewJSONHandler(cfg.Output, &cfg.Handler)})
 
 	slog.SetDefault(log)
 
-	return &Log{log}
+	return &Log{log, level}
+}
+
+// INFO: safe to call while logging, takes the same values as WithLevel
+func (l *Log) SetLevel(lvl string) {
+	l.level.Set(levelOf(lvl))
 }
 
-func (l *Log) Info(format string, msg ...any) {
-	l.Logger.Info(fmt.Sprintf(format, msg...))
+func (l *Log) Info(ctx context.Context, format string, msg ...any) {
+	l.Logger.InfoContext(ctx, fmt.Sprintf(format, msg...))
 }
 
-func (l *Log) Debug(err error, format string, msg ...any) {
-	l.Logger.Debug(
+func (l *Log) Debug(ctx context.Context, err error, format string, msg ...any) {
+	l.Logger.DebugContext(
+		ctx,
 		fmt.Sprintf(format, msg...),
 		handleErr(err),
 	)
 }
 
-func (l *Log) Error(err error, format string, msg ...any) {
-	l.Logger.Error(
+func (l *Log) Error(ctx context.Context, err error, format string, msg ...any) {
+	l.Logger.ErrorContext(
+		ctx,
 		fmt.Sprintf(format, msg...),
 		handleErr(err),
 	)
@@ -0,0 +1,114 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/v1adhope/auth-service/pkg/logger"
+)
+
+type testRecord struct {
+	Msg       string `json:"msg"`
+	Err       string `json:"err"`
+	RequestId string `json:"requestId"`
+	UserId    string `json:"userId"`
+	SessionId string `json:"sessionId"`
+}
+
+func TestContextFields(t *testing.T) {
+	tcs := []struct {
+		key       string
+		requestId string
+		userId    string
+		sessionId string
+	}{
+		{
+			key:       "Case 1",
+			requestId: "req-1",
+		},
+		{
+			key:       "Case 2",
+			requestId: "req-2",
+			userId:    "adb21fec-7892-416a-bbfc-9b2d77e8db4a",
+		},
+		{
+			key:       "Case 3",
+			requestId: "req-3",
+			userId:    "01f20929-dc51-4edb-a472-5672f4678fa2",
+			sessionId: "1ef616d-fc71-6082-9aec-0242ac120003",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run("", func(t *testing.T) {
+			out := &bytes.Buffer{}
+			log := logger.New(
+				logger.WithLevel("debug"),
+				logger.WithOutput(out),
+			)
+
+			ctx := logger.NewContext(context.Background(), tc.requestId)
+
+			// INFO: ids set after the context was built must be visible too
+			logger.SetUserId(ctx, tc.userId)
+			logger.SetSessionId(ctx, tc.sessionId)
+
+			log.Error(ctx, errors.New("some err"), "%s", "some msg")
+
+			sut := testRecord{}
+			err := json.Unmarshal(out.Bytes(), &sut)
+
+			assert.NoError(t, err, tc.key)
+			assert.Equal(t, "some msg", sut.Msg, tc.key)
+			assert.Equal(t, "some err", sut.Err, tc.key)
+			assert.Equal(t, tc.requestId, sut.RequestId, tc.key)
+			assert.Equal(t, tc.userId, sut.UserId, tc.key)
+			assert.Equal(t, tc.sessionId, sut.SessionId, tc.key)
+			assert.Equal(t, tc.requestId, logger.RequestId(ctx), tc.key)
+		})
+	}
+}
+
+func TestContextFieldsWithoutRequest(t *testing.T) {
+	out := &bytes.Buffer{}
+	log := logger.New(
+		logger.WithOutput(out),
+	)
+
+	ctx := context.Background()
+
+	logger.SetUserId(ctx, "adb21fec-7892-416a-bbfc-9b2d77e8db4a")
+
+	log.Info(ctx, "%s", "some msg")
+
+	sut := testRecord{}
+	err := json.Unmarshal(out.Bytes(), &sut)
+
+	assert.NoError(t, err)
+	assert.Empty(t, sut.RequestId)
+	assert.Empty(t, sut.UserId)
+	assert.Empty(t, logger.RequestId(ctx))
+}
+
+func TestSetLevel(t *testing.T) {
+	out := &bytes.Buffer{}
+	log := logger.New(
+		logger.WithLevel("error"),
+		logger.WithOutput(out),
+	)
+
+	ctx := context.Background()
+
+	log.Info(ctx, "%s", "hidden")
+
+	assert.Empty(t, out.String())
+
+	log.SetLevel("debug")
+	log.Debug(ctx, nil, "%s", "shown")
+
+	assert.Contains(t, out.String(), "shown")
+}
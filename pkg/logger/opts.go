@@ -1,23 +1,48 @@
 package logger
 
-import "log/slog"
+import (
+	"io"
+	"log/slog"
+	"os"
+)
 
-type Option func(*slog.HandlerOptions)
+type Option func(*Config)
+
+type Config struct {
+	Handler slog.HandlerOptions
+	Output  io.Writer
+}
 
 func WithLevel(lvl string) Option {
-	return func(opts *slog.HandlerOptions) {
-		switch lvl {
-		case "debug":
-			opts.Level = slog.LevelDebug
-		case "error":
-			opts.Level = slog.LevelError
-		}
+	return func(cfg *Config) {
+		cfg.Handler.Level = levelOf(lvl)
+	}
+}
+
+// INFO: anything but debug and error is info
+func levelOf(lvl string) slog.Level {
+	switch lvl {
+	case "debug":
+		return slog.LevelDebug
+	case "error":
+		return slog.LevelError
+	}
+
+	return slog.LevelInfo
+}
+
+func WithOutput(w io.Writer) Option {
+	return func(cfg *Config) {
+		cfg.Output = w
 	}
 }
 
-func config(opts ...Option) slog.HandlerOptions {
-	cfg := slog.HandlerOptions{
-		Level: slog.LevelInfo,
+func config(opts ...Option) Config {
+	cfg := Config{
+		Handler: slog.HandlerOptions{
+			Level: slog.LevelInfo,
+		},
+		Output: os.Stdout,
 	}
 
 	for _, opt := range opts {
@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const Redacted = "[REDACTED]"
+
+// INFO: keys are matched case-insensitively at any depth, non json input is fully redacted
+func RedactJSON(body []byte, keys ...string) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return Redacted
+	}
+
+	set := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		set[strings.ToLower(k)] = struct{}{}
+	}
+
+	redacted, err := json.Marshal(redact(v, set))
+	if err != nil {
+		return Redacted
+	}
+
+	return string(redacted)
+}
+
+func redact(v any, keys map[string]struct{}) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, inner := range t {
+			if _, ok := keys[strings.ToLower(k)]; ok {
+				t[k] = Redacted
+				continue
+			}
+
+			t[k] = redact(inner, keys)
+		}
+	case []any:
+		for i, inner := range t {
+			t[i] = redact(inner, keys)
+		}
+	}
+
+	return v
+}
@@ -0,0 +1,45 @@
+package logger_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/v1adhope/auth-service/pkg/logger"
+)
+
+func TestRedactJSON(t *testing.T) {
+	tcs := []struct {
+		key      string
+		input    string
+		expected string
+	}{
+		{
+			key:      "Token pair",
+			input:    `{"accessToken":"some access","refreshToken":"some refresh"}`,
+			expected: `{"accessToken":"[REDACTED]","refreshToken":"[REDACTED]"}`,
+		},
+		{
+			key:      "Nested and case insensitive",
+			input:    `{"data":[{"RefreshToken":"some refresh","userId":"11"}]}`,
+			expected: `{"data":[{"RefreshToken":"[REDACTED]","userId":"11"}]}`,
+		},
+		{
+			key:      "Not json",
+			input:    `accessToken=some access`,
+			expected: logger.Redacted,
+		},
+		{
+			key:      "Empty",
+			input:    ``,
+			expected: ``,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run("", func(t *testing.T) {
+			sut := logger.RedactJSON([]byte(tc.input), "accessToken", "refreshToken")
+
+			assert.Equal(t, tc.expected, sut, tc.key)
+		})
+	}
+}
@@ -1,9 +1,12 @@
 package postgresql
 
+import "log/slog"
+
 type Option func(*Config)
 
 type Config struct {
 	ConnStr string
+	Logger  *slog.Logger
 }
 
 func WithConnStr(connStr string) Option {
@@ -12,6 +15,12 @@ func WithConnStr(connStr string) Option {
 	}
 }
 
+func WithLogger(l *slog.Logger) Option {
+	return func(cfg *Config) {
+		cfg.Logger = l
+	}
+}
+
 // INFO: panic if connStr not defined
 func config(opts ...Option) Config {
 	cfg := Config{}
@@ -2,9 +2,11 @@ package postgresql
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -16,9 +18,18 @@ type Postgres struct {
 func Build(ctx context.Context, opts ...Option) (*Postgres, error) {
 	cfg := config(opts...)
 
-	pool, err := pgxpool.New(ctx, cfg.ConnStr)
+	poolCfg, err := pgxpool.ParseConfig(cfg.ConnStr)
 	if err != nil {
-		return nil, fmt.Errorf("postgresql: postgresql: Build: New: %w", err)
+		return nil, fmt.Errorf("postgresql: postgresql: Build: ParseConfig: %w", err)
+	}
+
+	if cfg.Logger != nil {
+		poolCfg.ConnConfig.Tracer = queryTracer(cfg.Logger)
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
+	if err != nil {
+		return nil, fmt.Errorf("postgresql: postgresql: Build: NewWithConfig: %w", err)
 	}
 
 	if err := pool.Ping(ctx); err != nil {
@@ -33,3 +44,48 @@ func Build(ctx context.Context, opts ...Option) (*Postgres, error) {
 func (p *Postgres) Close() {
 	p.Pool.Close()
 }
+
+// INFO: unit of work, commits when fn returns nil and rolls back otherwise,
+// errors returned by fn are passed through unwrapped
+func (p *Postgres) InTx(ctx context.Context, fn func(tx pgx.Tx) error) (err error) {
+	tx, err := p.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("postgresql: postgresql: InTx: Begin: %w", err)
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		if rbErr := tx.Rollback(ctx); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			err = errors.Join(err, fmt.Errorf("postgresql: postgresql: InTx: Rollback: %w", rbErr))
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("postgresql: postgresql: InTx: Commit: %w", err)
+	}
+
+	return nil
+}
+
+func (p *Postgres) Ping(ctx context.Context) error {
+	if err := p.Pool.Ping(ctx); err != nil {
+		return fmt.Errorf("postgresql: postgresql: Ping: %w", err)
+	}
+
+	return nil
+}
+
+// INFO: reads the golang-migrate bookkeeping table
+func (p *Postgres) SchemaVersion(ctx context.Context) (version int64, dirty bool, err error) {
+	if err := p.Pool.QueryRow(ctx, "select version, dirty from schema_migrations limit 1").Scan(&version, &dirty); err != nil {
+		return 0, false, fmt.Errorf("postgresql: postgresql: SchemaVersion: Scan: %w", err)
+	}
+
+	return version, dirty, nil
+}
@@ -0,0 +1,40 @@
+package postgresql
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5/tracelog"
+)
+
+// INFO: query args are dropped, they carry token hashes
+func queryTracer(l *slog.Logger) *tracelog.TraceLog {
+	return &tracelog.TraceLog{
+		Logger: tracelog.LoggerFunc(func(ctx context.Context, level tracelog.LogLevel, msg string, data map[string]any) {
+			attrs := make([]slog.Attr, 0, len(data))
+
+			for k, v := range data {
+				if k == "args" {
+					continue
+				}
+
+				attrs = append(attrs, slog.Any(k, v))
+			}
+
+			l.LogAttrs(ctx, slogLevel(level), "postgresql: "+msg, attrs...)
+		}),
+		LogLevel: tracelog.LogLevelInfo,
+	}
+}
+
+// INFO: successful queries are noisy so they go to debug
+func slogLevel(level tracelog.LogLevel) slog.Level {
+	switch level {
+	case tracelog.LogLevelError:
+		return slog.LevelError
+	case tracelog.LogLevelWarn:
+		return slog.LevelWarn
+	}
+
+	return slog.LevelDebug
+}
@@ -0,0 +1,29 @@
+package redis
+
+type Option func(*Config)
+
+type Config struct {
+	Url string
+}
+
+// INFO: redis://[user:password@]host:port/db, rediss:// for tls
+func WithUrl(url string) Option {
+	return func(cfg *Config) {
+		cfg.Url = url
+	}
+}
+
+// INFO: panic if url not defined
+func config(opts ...Option) Config {
+	cfg := Config{}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if cfg.Url == "" {
+		panic("redis: define url")
+	}
+
+	return cfg
+}
@@ -0,0 +1,42 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+type Redis struct {
+	Client *goredis.Client
+}
+
+func Build(ctx context.Context, opts ...Option) (*Redis, error) {
+	cfg := config(opts...)
+
+	clientOpts, err := goredis.ParseURL(cfg.Url)
+	if err != nil {
+		return nil, fmt.Errorf("redis: redis: Build: ParseURL: %w", err)
+	}
+
+	client := goredis.NewClient(clientOpts)
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("redis: redis: Build: Ping: %w", err)
+	}
+
+	return &Redis{client}, nil
+}
+
+func (r *Redis) Close() {
+	r.Client.Close()
+}
+
+func (r *Redis) Ping(ctx context.Context) error {
+	if err := r.Client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("redis: redis: Ping: %w", err)
+	}
+
+	return nil
+}
@@ -41,6 +41,10 @@ func DecryptByGcm(ciphertext, key []byte) ([]byte, error) {
 	}
 
 	nonceSize := aesgcm.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, fmt.Errorf("serialization: encryption: DecryptByGcm: ciphertext too short")
+	}
+
 	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
 
 	text, err := aesgcm.Open(nil, nonce, ciphertext, nil)
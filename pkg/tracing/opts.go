@@ -0,0 +1,61 @@
+package tracing
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOtlp   = "otlp"
+)
+
+type Option func(*Config)
+
+type Config struct {
+	Exporter    string
+	Endpoint    string
+	Insecure    bool
+	ServiceName string
+	SampleRatio float64
+}
+
+func WithExporter(e string) Option {
+	return func(cfg *Config) {
+		cfg.Exporter = e
+	}
+}
+
+func WithEndpoint(e string) Option {
+	return func(cfg *Config) {
+		cfg.Endpoint = e
+	}
+}
+
+func WithInsecure(i bool) Option {
+	return func(cfg *Config) {
+		cfg.Insecure = i
+	}
+}
+
+func WithServiceName(sn string) Option {
+	return func(cfg *Config) {
+		cfg.ServiceName = sn
+	}
+}
+
+func WithSampleRatio(sr float64) Option {
+	return func(cfg *Config) {
+		cfg.SampleRatio = sr
+	}
+}
+
+func config(opts ...Option) Config {
+	cfg := Config{
+		Exporter:    ExporterNone,
+		ServiceName: "auth-service",
+		SampleRatio: 1,
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return cfg
+}
@@ -0,0 +1,81 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+type Tracing struct {
+	provider *sdktrace.TracerProvider
+}
+
+// INFO: installs the global tracer provider and W3C propagators,
+// with ExporterNone spans are still propagated but not exported
+func Build(ctx context.Context, opts ...Option) (*Tracing, error) {
+	cfg := config(opts...)
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	res := resource.NewSchemaless(attribute.String("service.name", cfg.ServiceName))
+
+	providerOpts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	}
+
+	switch cfg.Exporter {
+	case ExporterNone:
+	case ExporterStdout:
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, fmt.Errorf("tracing: tracing: Build: stdouttrace.New: %w", err)
+		}
+
+		providerOpts = append(providerOpts, sdktrace.WithBatcher(exp))
+	case ExporterOtlp:
+		exporterOpts := []otlptracehttp.Option{}
+
+		if cfg.Endpoint != "" {
+			exporterOpts = append(exporterOpts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+
+		if cfg.Insecure {
+			exporterOpts = append(exporterOpts, otlptracehttp.WithInsecure())
+		}
+
+		exp, err := otlptracehttp.New(ctx, exporterOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("tracing: tracing: Build: otlptracehttp.New: %w", err)
+		}
+
+		providerOpts = append(providerOpts, sdktrace.WithBatcher(exp))
+	default:
+		return nil, fmt.Errorf("tracing: tracing: Build: unknown exporter %q", cfg.Exporter)
+	}
+
+	provider := sdktrace.NewTracerProvider(providerOpts...)
+
+	otel.SetTracerProvider(provider)
+
+	return &Tracing{provider}, nil
+}
+
+func (t *Tracing) Shutdown(ctx context.Context) error {
+	if err := t.provider.Shutdown(ctx); err != nil {
+		return fmt.Errorf("tracing: tracing: Shutdown: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package vault
+
+import (
+	"net/http"
+	"time"
+)
+
+type Option func(*Config)
+
+type Config struct {
+	Addr      string
+	Token     string
+	Path      string
+	Namespace string
+	Client    *http.Client
+}
+
+// INFO: e.g. https://vault.example.com:8200
+func WithAddr(addr string) Option {
+	return func(cfg *Config) {
+		cfg.Addr = addr
+	}
+}
+
+func WithToken(token string) Option {
+	return func(cfg *Config) {
+		cfg.Token = token
+	}
+}
+
+// INFO: full api path of the secret, "secret/data/auth-service" for kv v2,
+// "secret/auth-service" for kv v1
+func WithPath(path string) Option {
+	return func(cfg *Config) {
+		cfg.Path = path
+	}
+}
+
+func WithNamespace(ns string) Option {
+	return func(cfg *Config) {
+		cfg.Namespace = ns
+	}
+}
+
+func WithHttpClient(c *http.Client) Option {
+	return func(cfg *Config) {
+		cfg.Client = c
+	}
+}
+
+// INFO: panic if addr or path not defined
+func config(opts ...Option) Config {
+	cfg := Config{
+		Client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if cfg.Addr == "" {
+		panic("vault: define addr")
+	}
+
+	if cfg.Path == "" {
+		panic("vault: define path")
+	}
+
+	return cfg
+}
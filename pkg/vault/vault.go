@@ -0,0 +1,95 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const _maxErrBody = 512
+
+// INFO: reads one kv secret over the vault http api,
+// anything speaking the same protocol works too
+type Vault struct {
+	url       string
+	token     string
+	namespace string
+	client    *http.Client
+}
+
+func New(opts ...Option) *Vault {
+	cfg := config(opts...)
+
+	return &Vault{
+		url:       strings.TrimSuffix(cfg.Addr, "/") + "/v1/" + strings.TrimPrefix(cfg.Path, "/"),
+		token:     cfg.Token,
+		namespace: cfg.Namespace,
+		client:    cfg.Client,
+	}
+}
+
+type kvResp struct {
+	Data json.RawMessage `json:"data"`
+}
+
+// INFO: kv v2 nests the values under data.data with metadata aside
+type kvV2Data struct {
+	Data     map[string]any `json:"data"`
+	Metadata map[string]any `json:"metadata"`
+}
+
+// INFO: non string values are skipped
+func (v *Vault) Secrets(ctx context.Context) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("vault: vault: Secrets: NewRequest: %w", err)
+	}
+
+	if v.token != "" {
+		req.Header.Set("X-Vault-Token", v.token)
+	}
+
+	if v.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.namespace)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("vault: vault: Secrets: Do: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, _maxErrBody))
+
+		return nil, fmt.Errorf("vault: vault: Secrets: unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	kv := kvResp{}
+
+	if err := json.NewDecoder(resp.Body).Decode(&kv); err != nil {
+		return nil, fmt.Errorf("vault: vault: Secrets: Decode: %w", err)
+	}
+
+	data := map[string]any{}
+
+	v2 := kvV2Data{}
+	if err := json.Unmarshal(kv.Data, &v2); err == nil && v2.Data != nil && v2.Metadata != nil {
+		data = v2.Data
+	} else if err := json.Unmarshal(kv.Data, &data); err != nil {
+		return nil, fmt.Errorf("vault: vault: Secrets: Unmarshal: %w", err)
+	}
+
+	secrets := make(map[string]string, len(data))
+
+	for k, val := range data {
+		if s, ok := val.(string); ok {
+			secrets[k] = s
+		}
+	}
+
+	return secrets, nil
+}
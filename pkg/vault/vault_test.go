@@ -0,0 +1,79 @@
+package vault_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/v1adhope/auth-service/pkg/vault"
+)
+
+func TestSecrets(t *testing.T) {
+	tcs := []struct {
+		key      string
+		path     string
+		status   int
+		body     string
+		expected map[string]string
+	}{
+		{
+			key:      "Kv v2",
+			path:     "/v1/secret/data/auth-service",
+			status:   http.StatusOK,
+			body:     `{"data":{"data":{"tokens_access_key":"a","tokens_refresh_key":"b","port":5432},"metadata":{"version":3}}}`,
+			expected: map[string]string{"tokens_access_key": "a", "tokens_refresh_key": "b"},
+		},
+		{
+			key:      "Kv v1",
+			path:     "/v1/secret/auth-service",
+			status:   http.StatusOK,
+			body:     `{"data":{"tokens_access_key":"a","data":"b"}}`,
+			expected: map[string]string{"tokens_access_key": "a", "data": "b"},
+		},
+		{
+			key:    "Forbidden",
+			path:   "/v1/secret/data/auth-service",
+			status: http.StatusForbidden,
+			body:   `{"errors":["permission denied"]}`,
+		},
+		{
+			key:    "Not json",
+			path:   "/v1/secret/data/auth-service",
+			status: http.StatusOK,
+			body:   `<html>`,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.key, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != tc.path || r.Header.Get("X-Vault-Token") != "token" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+
+			v := vault.New(
+				vault.WithAddr(srv.URL+"/"),
+				vault.WithToken("token"),
+				vault.WithPath(tc.path[len("/v1/"):]),
+			)
+
+			sut, err := v.Secrets(context.Background())
+
+			if tc.expected == nil {
+				assert.Error(t, err, tc.key)
+				return
+			}
+
+			assert.NoError(t, err, tc.key)
+			assert.Equal(t, tc.expected, sut, tc.key)
+		})
+	}
+}